@@ -3,29 +3,36 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
 	_config "github.com/jcelaya775/gwt/internal/config"
-	"github.com/jcelaya775/gwt/internal/connector"
+	_connector "github.com/jcelaya775/gwt/internal/connector"
 	"github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/jcelaya775/gwt/internal/pipeline"
 	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/jcelaya775/gwt/internal/tmux"
 	"github.com/jcelaya775/gwt/internal/utils"
 	"github.com/jcelaya775/gwt/internal/zoxide"
 	"github.com/spf13/cobra"
+	"os"
+	"slices"
 	"strings"
 )
 
-func Add(git *git.Git, selecter *selecter.Select, zoxide *zoxide.Zoxide, connector *connector.Connector) *cobra.Command {
+func Add(git *git.Git, selecter *selecter.Select, zoxide *zoxide.Zoxide, connector *_connector.Connector, tmux *tmux.Tmux, nav *navigator.Navigator) *cobra.Command {
 	var noPull bool
 	var noSync bool
 	var forceAdd bool
-	var seshConnect bool
-	var webStormConnect bool
-	var goLandConnect bool
-	var pyCharmConnect bool
-	var intelliJConnect bool
-	var cLionConnect bool
-	var riderConnect bool
-	var dataGripConnect bool
+	var open []string
+	var noOpen bool
+	var branchType string
+	var detachHooks bool
+	var keepOnFailure bool
+	var rollbackOnFailure bool
+	// Flags from before --open, one per opener
+	legacyOpenFlags := make(map[string]*bool)
 
 	addCmd := &cobra.Command{
 		Use:     "add <branch> [commit-ish]",
@@ -59,11 +66,21 @@ func Add(git *git.Git, selecter *selecter.Select, zoxide *zoxide.Zoxide, connect
 			if err != nil {
 				return err
 			}
+			if len(args) == 0 && branchType != "" {
+				return errors.New("--type needs a description of the branch, e.g. gwt add \"Fix login redirect\" --type fix")
+			}
 
-			if !noSync {
-				if err := git.Fetch(); err != nil {
-					return err
-				}
+			// Fetching comes first, so the branches to select from and the plan include the remote branches
+			addPipeline := pipeline.New()
+			fetchStep := pipeline.Step{
+				Name: "fetch remote branches",
+				Skip: noSync,
+				Run: func(p *pipeline.Pipeline) error {
+					return git.Fetch()
+				},
+			}
+			if err := addPipeline.Run([]pipeline.Step{fetchStep}); err != nil {
+				return err
 			}
 
 			if len(args) == 0 {
@@ -90,6 +107,15 @@ func Add(git *git.Git, selecter *selecter.Select, zoxide *zoxide.Zoxide, connect
 				commitish = args[1]
 			}
 
+			if branchType != "" {
+				if branch, err = config.BranchNaming.FromText(branchType, branch); err != nil {
+					return err
+				}
+			}
+			if branch, err = newBranchName(git, config, branch, commitish != ""); err != nil {
+				return err
+			}
+
 			worktreeAlreadyExists, err := git.WorktreeExists(strings.TrimPrefix(branch, "origin/"))
 			if err != nil {
 				return err
@@ -98,77 +124,297 @@ func Add(git *git.Git, selecter *selecter.Select, zoxide *zoxide.Zoxide, connect
 				return fmt.Errorf("worktree for branch '%s' already exists", branch)
 			}
 
-			worktreePath, err := git.AddWorktree(config, branch, commitish, noPull, forceAdd)
-			if err != nil {
-				return err
-			}
-			boldStyle := lipgloss.NewStyle().Bold(true)
-			fmt.Printf("Worktree for branch %s added successfully.\n\n", boldStyle.Render(branch))
+			branchConfig := config.ForBranch(strings.TrimPrefix(branch, "origin/"))
 
-			if err = zoxide.AddPath(worktreePath); err != nil {
-				return err
+			// --open replaces the openers from the config, and the legacy flags add to either
+			openers := branchConfig.Open
+			if cmd.Flags().Changed("open") {
+				openers = open
 			}
-			if seshConnect {
-				if err = connector.SeshConnect(worktreePath); err != nil {
-					return err
+			for _, name := range _connector.OpenerNames {
+				if enabled, ok := legacyOpenFlags[name]; ok && *enabled && !slices.Contains(openers, name) {
+					openers = append(openers, name)
 				}
 			}
-
-			if webStormConnect {
-				if err = connector.WebstormConnect(worktreePath); err != nil {
-					return err
-				}
+			if noOpen {
+				openers = nil
 			}
-			if goLandConnect {
-				if err = connector.GoLandConnect(worktreePath); err != nil {
-					return err
-				}
+			if err := _connector.ValidateOpeners(openers); err != nil {
+				return err
 			}
-			if pyCharmConnect {
-				if err = connector.PyCharmConnect(worktreePath); err != nil {
-					return err
-				}
+
+			plan, err := git.PlanWorktree(config, branch, commitish)
+			if err != nil {
+				return err
 			}
-			if intelliJConnect {
-				if err = connector.IntelliJConnect(worktreePath); err != nil {
-					return err
-				}
+
+			foregroundHooks, backgroundHooks := branchConfig.Hooks.PostAdd.Split()
+			if detachHooks {
+				foregroundHooks, backgroundHooks = nil, branchConfig.Hooks.PostAdd
 			}
-			if cLionConnect {
-				if err = connector.CLionConnect(worktreePath); err != nil {
-					return err
-				}
+			seshConnect := slices.Contains(openers, _connector.Sesh)
+			initCommands := branchConfig.InitCommands
+			var stages []_hooks.Stage
+			if len(backgroundHooks) > 0 {
+				stages = append(stages, _hooks.Stage{Event: _config.PostAdd, Hooks: backgroundHooks})
 			}
-			if riderConnect {
-				if err = connector.RiderConnect(worktreePath); err != nil {
-					return err
+			// Commands sent to the tmux session already run without blocking gwt
+			if detachHooks && !seshConnect && len(initCommands) > 0 {
+				initHooks := make(_config.HookList, 0, len(initCommands))
+				for _, command := range initCommands {
+					initHooks = append(initHooks, _config.Hook{Run: command})
 				}
+				stages = append(stages, _hooks.Stage{Event: "init", Hooks: initHooks})
+				initCommands = nil
 			}
-			if dataGripConnect {
-				if err = connector.DataGripConnect(worktreePath); err != nil {
-					return err
-				}
+
+			hookCtx := commandContext(git, _config.PostAdd, plan.Path, plan.Branch, plan.Branch, plan.ParentBranch)
+			steps := []pipeline.Step{
+				{
+					Name: "run pre_add hooks",
+					Skip: len(branchConfig.Hooks.PreAdd) == 0,
+					Run: func(p *pipeline.Pipeline) error {
+						preAddCtx := hookCtx
+						preAddCtx.Event = _config.PreAdd
+						if err := _hooks.Run(branchConfig.Hooks.PreAdd, preAddCtx, os.Stdout, branchConfig.Hooks.Jobs); err != nil {
+							return err
+						}
+						fmt.Println()
+						return nil
+					},
+				},
+				{
+					Name: "pull base branch",
+					Skip: noPull,
+					Run: func(p *pipeline.Pipeline) error {
+						return git.PullBaseBranch(plan)
+					},
+				},
+				{
+					Name: "create branch",
+					Skip: !plan.NewBranch,
+					Run: func(p *pipeline.Pipeline) error {
+						if err := git.CreateBranch(plan); err != nil {
+							return err
+						}
+						p.Record("branch "+plan.Branch, func() error { return git.DeleteBranch(plan.Branch) })
+						return nil
+					},
+				},
+				{
+					Name: "create worktree",
+					Run: func(p *pipeline.Pipeline) error {
+						if err := git.CreateWorktree(plan, forceAdd); err != nil {
+							return err
+						}
+						p.Record("worktree "+plan.Path, func() error { return git.RemoveWorktree(plan.Branch, 2, true) })
+						if !utils.DryRun() {
+							fmt.Printf("Worktree for branch %s added successfully.\n\n", boldStyle.Render(branch))
+						}
+						return nil
+					},
+				},
+				{
+					Name: "copy files",
+					Run: func(p *pipeline.Pipeline) error {
+						return copyWorktreeFiles(git, config, branchConfig, plan.Path)
+					},
+				},
+				{
+					Name: "run post_add hooks",
+					Run: func(p *pipeline.Pipeline) error {
+						return _hooks.Run(foregroundHooks, hookCtx, os.Stdout, branchConfig.Hooks.Jobs)
+					},
+				},
+				// Zoxide and opening only warn when they fail
+				{
+					Name: "add to zoxide",
+					Run: func(p *pipeline.Pipeline) error {
+						if err := zoxide.AddPath(plan.Path); err != nil {
+							fmt.Fprintln(os.Stderr, orangeStyle.Render(fmt.Sprintf("Warning: failed to add the worktree to zoxide: %v", err)))
+							return nil
+						}
+						p.Record("zoxide entry "+plan.Path, func() error { return zoxide.RemovePath(plan.Path) })
+						return nil
+					},
+				},
+				{
+					Name: "open worktree",
+					Skip: len(openers) == 0,
+					Run: func(p *pipeline.Pipeline) error {
+						// Sessions that did not exist before belong to the new worktree
+						sessionsBefore, err := tmux.ListSessions()
+						openErr := connector.Open(openers, plan.Path)
+						if err == nil {
+							var sessionsAfter []string
+							sessionsAfter, err = tmux.ListSessions()
+							for _, session := range sessionsAfter {
+								if !slices.Contains(sessionsBefore, session) {
+									p.Record("tmux session "+session, func() error { return tmux.KillSession(session) })
+								}
+							}
+						}
+						if err := errors.Join(openErr, err); err != nil {
+							fmt.Fprintln(os.Stderr, orangeStyle.Render(fmt.Sprintf("Warning: failed to open the worktree: %v", err)))
+						}
+						return nil
+					},
+				},
+				{
+					Name: "run init commands",
+					Run: func(p *pipeline.Pipeline) error {
+						commandCtx := commandContext(git, "init", plan.Path, plan.Branch, plan.Branch, plan.ParentBranch)
+						return utils.RunCommands(initCommands, commandCtx, seshConnect)
+					},
+				},
+				{
+					Name: "change directory",
+					Skip: !nav.Enabled(),
+					Run: func(p *pipeline.Pipeline) error {
+						return nav.ChangeDir(plan.Path)
+					},
+				},
+				// Background hooks outlive gwt, so they start last and never fail the pipeline
+				{
+					Name: "start background hooks",
+					Skip: len(stages) == 0,
+					Run: func(p *pipeline.Pipeline) error {
+						if err := _hooks.StartBackground(stages, hookCtx, branchConfig.Hooks.Jobs, branchConfig.Hooks.Notify); err != nil {
+							fmt.Fprintln(os.Stderr, orangeStyle.Render(fmt.Sprintf("Warning: %v. Run the post_add hooks again with gwt hooks run post_add.", err)))
+							return nil
+						}
+						if utils.DryRun() {
+							return nil
+						}
+						fmt.Println(faintStyle.Render("Setting the worktree up in the background. gwt list shows when it is ready, and gwt logs what its hooks print."))
+						return nil
+					},
+				},
 			}
 
-			if err = utils.RunCommands(config.InitCommands, worktreePath, seshConnect, ""); err != nil {
+			cmd.SilenceUsage = true
+			if err := ensureTrusted(git, os.Stdout); err != nil {
 				return err
 			}
 
-			return nil
+			err = addPipeline.Run(steps)
+			var stepErr *pipeline.StepError
+			if !errors.As(err, &stepErr) || len(addPipeline.Artefacts()) == 0 || utils.DryRun() {
+				return err
+			}
+			return rollbackAdd(addPipeline, stepErr, keepOnFailure, rollbackOnFailure)
 		},
 	}
 
 	addCmd.Flags().BoolVar(&noPull, "no-pull", false, "Do not pull the base branch before creating the worktree")
 	addCmd.Flags().BoolVar(&noSync, "no-sync", false, "Do not fetch remote branches before creating the worktree")
 	addCmd.Flags().BoolVarP(&forceAdd, "force", "f", false, "Checkout branch even if already checked out in another worktree")
-	addCmd.Flags().BoolVar(&seshConnect, "sesh", false, "Connect to the worktree with Sesh")
-	addCmd.Flags().BoolVar(&webStormConnect, "webstorm", false, "Open the new worktree in WebStorm")
-	addCmd.Flags().BoolVar(&intelliJConnect, "idea", false, "Open the new worktree in IntelliJ IDEA")
-	addCmd.Flags().BoolVar(&pyCharmConnect, "pycharm", false, "Open the new worktree in PyCharm")
-	addCmd.Flags().BoolVar(&cLionConnect, "clion", false, "Open the new worktree in CLion")
-	addCmd.Flags().BoolVar(&riderConnect, "rider", false, "Open the new worktree in Rider")
-	addCmd.Flags().BoolVar(&goLandConnect, "goland", false, "Open the new worktree in GoLand")
-	addCmd.Flags().BoolVar(&dataGripConnect, "datagrip", false, "Open the new worktree in DataGrip")
+	addCmd.Flags().StringArrayVarP(&open, "open", "o", nil, fmt.Sprintf("Open the new worktree with a tool instead of the ones in defaults.open. Can be repeated. One of %s", strings.Join(_connector.OpenerNames, ", ")))
+	addCmd.Flags().BoolVar(&noOpen, "no-open", false, "Do not open the new worktree with any tool")
+	addCmd.MarkFlagsMutuallyExclusive("open", "no-open")
+	_ = addCmd.RegisterFlagCompletionFunc("open", func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		return _connector.OpenerNames, cobra.ShellCompDirectiveNoFileComp
+	})
+	addCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false, "Keep the worktree, branch, tmux session and zoxide entry when a later step fails instead of offering to remove them")
+	addCmd.Flags().BoolVar(&rollbackOnFailure, "rollback-on-failure", false, "Remove the worktree, branch, tmux session and zoxide entry when a later step fails without asking")
+	addCmd.MarkFlagsMutuallyExclusive("keep-on-failure", "rollback-on-failure")
+	addCmd.Flags().BoolVar(&detachHooks, "detach-hooks", false, "Run the post_add hooks and init_commands in the background instead of waiting for them")
+	addCmd.Flags().StringVarP(&branchType, "type", "t", "", "Create a branch of this type named after the free text given as the branch, e.g. --type fix")
+	_ = addCmd.RegisterFlagCompletionFunc("type", func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+		if err := git.SetWorktreeRoot(); err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		config, err := _config.LoadConfig(git.GetWorktreeRoot())
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return config.BranchNaming.Types(), cobra.ShellCompDirectiveNoFileComp
+	})
+	for _, name := range _connector.OpenerNames {
+		legacyOpenFlags[name] = addCmd.Flags().Bool(name, false, "Open the new worktree with "+name)
+		_ = addCmd.Flags().MarkDeprecated(name, fmt.Sprintf("use --open %s instead", name))
+	}
 
 	return addCmd
 }
+
+// rollbackAdd asks whether to undo what gwt add created. Without a terminal only rollbackOnFailure undoes it
+func rollbackAdd(addPipeline *pipeline.Pipeline, stepErr *pipeline.StepError, keepOnFailure bool, rollbackOnFailure bool) error {
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, orangeStyle.Render(fmt.Sprintf("gwt add failed to %s. It had created:", stepErr.Step)))
+	for _, artefact := range addPipeline.Artefacts() {
+		fmt.Fprintf(os.Stderr, "  %s\n", artefact.Description)
+	}
+
+	rollback := rollbackOnFailure
+	if !keepOnFailure && !rollbackOnFailure && term.IsTerminal(os.Stdin.Fd()) {
+		rollback = true
+		err := huh.NewConfirm().
+			Title("Remove them again?").
+			Affirmative("Yes").
+			Negative("No").
+			Value(&rollback).
+			Run()
+		if err != nil {
+			return errors.Join(stepErr, err)
+		}
+	}
+	if !rollback {
+		fmt.Fprintln(os.Stderr, faintStyle.Render("Kept them. Remove the worktree and its branch with gwt remove when you are done."))
+		return stepErr
+	}
+
+	rollbackErr := addPipeline.Rollback(func(artefact pipeline.Artefact, err error) {
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Removed %s\n", artefact.Description)
+		}
+	})
+	return errors.Join(stepErr, rollbackErr)
+}
+
+// newBranchName returns the branch gwt add checks out, checking new ones with checkNewBranchName
+func newBranchName(git *git.Git, config *_config.Config, branch string, isNew bool) (string, error) {
+	if strings.HasPrefix(branch, "origin/") {
+		return branch, nil
+	}
+	if !isNew {
+		existsLocally, err := git.BranchExistsLocally(branch)
+		if err != nil {
+			return "", err
+		}
+		existsRemotely, err := git.BranchExistsRemotely(branch)
+		if err != nil {
+			return "", err
+		}
+		if existsLocally || existsRemotely {
+			return branch, nil
+		}
+	}
+	return checkNewBranchName(git, &config.BranchNaming, branch, true)
+}
+
+// checkNewBranchName checks a new branch name against git and branch_naming, slugifying it first when configured
+func checkNewBranchName(git *git.Git, naming *_config.BranchNaming, branch string, suggestType bool) (string, error) {
+	if naming.Slugify {
+		if normalized := naming.Normalize(branch); normalized != branch {
+			fmt.Printf("Using branch name %s\n", boldStyle.Render(normalized))
+			branch = normalized
+		}
+	}
+	if err := git.CheckBranchName(branch); err != nil {
+		if normalized := naming.Normalize(branch); normalized != "" && git.CheckBranchName(normalized) == nil {
+			return "", fmt.Errorf("%w. Did you mean '%s'?", err, normalized)
+		}
+		return "", err
+	}
+	if err := naming.Check(branch); err != nil {
+		if normalized := naming.Normalize(branch); normalized != branch && naming.Check(normalized) == nil {
+			return "", fmt.Errorf("%w. Did you mean '%s'?", err, normalized)
+		}
+		if types := naming.Types(); suggestType && len(types) > 0 && !strings.Contains(branch, "/") {
+			return "", fmt.Errorf("%w. Describe the branch with --type instead, e.g. gwt add \"%s\" --type %s", err, branch, types[0])
+		}
+		return "", err
+	}
+	return branch, nil
+}
@@ -1,15 +1,27 @@
 package cmd
 
 import (
-	"github.com/jcelaya775/gwt/internal/git"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/utils"
 	"github.com/spf13/cobra"
+	"os"
 )
 
-func Clone(git *git.Git) *cobra.Command {
-	return &cobra.Command{
+func Clone(git *_git.Git) *cobra.Command {
+	var layout string
+
+	cloneCmd := &cobra.Command{
 		Use:   "clone <repo> [dir]",
 		Short: "Clone a git repository in a worktree setup",
-		Args:  cobra.RangeArgs(1, 2),
+		Long: `Clone a git repository in a worktree setup.
+
+The bare layout (default) stores the repository in <dir>/.bare with a .git file pointing at it, and checks out the
+default branch as the first worktree. The dummy layout checks out a placeholder branch named "dummy" in <dir> instead.`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var repoURL, dir string
 			repoURL = args[0]
@@ -17,12 +29,69 @@ func Clone(git *git.Git) *cobra.Command {
 				dir = args[1]
 			}
 
-			err := git.CloneRepo(repoURL, dir)
+			if !cmd.Flags().Changed("layout") {
+				config, err := _config.LoadConfig("")
+				if err != nil {
+					return err
+				}
+				if config.Defaults.Layout != "" {
+					layout = config.Defaults.Layout
+				}
+			}
+			cloneLayout, err := _git.ParseLayout(layout)
+			if err != nil {
+				return err
+			}
+
+			branch, worktreePath, err := git.CloneRepo(repoURL, dir, cloneLayout)
 			if err != nil {
 				return err
 			}
 
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+			// The worktree of the default branch is at the path worktree_path gives the branch, so it is named after it
+			name := branch
+			if !utils.DryRun() {
+				wt, err := git.GetWorktree(branch)
+				if err != nil {
+					return err
+				}
+				worktreePath, name = wt.Path, wt.Name
+			}
+			branchConfig := config.ForBranch(branch)
+			if len(branchConfig.Hooks.PostClone) == 0 {
+				return nil
+			}
+			fmt.Println()
+			cmd.SilenceUsage = true
+			if err := ensureTrusted(git, os.Stdout); err != nil {
+				return err
+			}
+			hookCtx := commandContext(git, _config.PostClone, worktreePath, name, branch, branch)
+			foregroundHooks, backgroundHooks := branchConfig.Hooks.PostClone.Split()
+			if err := _hooks.Run(foregroundHooks, hookCtx, os.Stdout, branchConfig.Hooks.Jobs); err != nil {
+				return err
+			}
+			if len(backgroundHooks) > 0 {
+				stages := []_hooks.Stage{{Event: _config.PostClone, Hooks: backgroundHooks}}
+				if err := _hooks.StartBackground(stages, hookCtx, branchConfig.Hooks.Jobs, branchConfig.Hooks.Notify); err != nil {
+					return err
+				}
+				if utils.DryRun() {
+					return nil
+				}
+				faintStyle := lipgloss.NewStyle().Faint(true)
+				fmt.Println(faintStyle.Render("Setting the worktree up in the background. gwt list shows when it is ready, and gwt logs what its hooks print."))
+			}
 			return nil
 		},
 	}
+
+	cloneCmd.Flags().StringVar(&layout, "layout", string(_git.LayoutBare), "Repository layout: bare or dummy. Defaults to defaults.layout from the global config")
+	_ = cloneCmd.RegisterFlagCompletionFunc("layout", cobra.FixedCompletions(_git.Layouts, cobra.ShellCompDirectiveNoFileComp))
+
+	return cloneCmd
 }
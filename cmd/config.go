@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+func Config(git *git.Git) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect, edit and validate the gwt configuration",
+		Long: `Inspect, edit and validate the gwt configuration.
+
+Configuration is merged from the following files, in increasing order of precedence:
+  global: $XDG_CONFIG_HOME/gwt/config.yml (default ~/.config/gwt/config.yml)
+  repo:   .gwt.yml in the repository root, shared with the team
+  local:  .gwt.local.yml in the repository root, not meant to be committed
+  flag:   the file passed with --config
+
+Mappings are merged key by key and other values replace the ones from lower layers. Tag a list with !append to
+append it to the list from lower layers instead, e.g. "init_commands: !append [make setup]".
+
+Keys are dotted paths with list indices, e.g. defaults.base_branch or init_commands[2].`,
+	}
+
+	configCmd.AddCommand(ConfigShow(git))
+	configCmd.AddCommand(ConfigGet(git))
+	configCmd.AddCommand(ConfigSet(git))
+	configCmd.AddCommand(ConfigUnset(git))
+	configCmd.AddCommand(ConfigEdit(git))
+	configCmd.AddCommand(ConfigExplain(git))
+	configCmd.AddCommand(ConfigValidate(git))
+	configCmd.AddCommand(ConfigSchema())
+
+	return configCmd
+}
+
+// configLayerFlags selects the configuration file that config get, set, unset and edit work on
+type configLayerFlags struct {
+	global bool
+	repo   bool
+	local  bool
+	file   string
+}
+
+func (f *configLayerFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.global, "global", false, "Use the global config file")
+	cmd.Flags().BoolVar(&f.repo, "repo", false, "Use the repository config file "+_config.ConfigFileName)
+	cmd.Flags().BoolVar(&f.local, "local", false, "Use the local config file "+_config.LocalConfigFileName)
+	cmd.Flags().StringVar(&f.file, "file", "", "Use the given config file")
+	cmd.MarkFlagsMutuallyExclusive("global", "repo", "local", "file")
+}
+
+func (f *configLayerFlags) selected() bool {
+	return f.global || f.repo || f.local || f.file != ""
+}
+
+// path returns the selected configuration file and the name of its layer. The repository layer is the default.
+func (f *configLayerFlags) path(git *git.Git) (string, string, error) {
+	switch {
+	case f.global:
+		path, err := _config.GlobalConfigPath()
+		return path, _config.LayerGlobal, err
+	case f.file != "":
+		path, err := filepath.Abs(f.file)
+		return path, _config.LayerExplicit, err
+	}
+
+	if err := git.SetWorktreeRoot(); err != nil {
+		return "", "", err
+	}
+	if f.local {
+		return filepath.Join(git.GetWorktreeRoot(), _config.LocalConfigFileName), _config.LayerLocal, nil
+	}
+	return filepath.Join(git.GetWorktreeRoot(), _config.ConfigFileName), _config.LayerRepo, nil
+}
+
+// saveConfigDocument validates and writes the document, printing any issues. A new local config file is added to the
+// repository's exclude file so it is not committed by accident.
+func saveConfigDocument(git *git.Git, document *_config.Document, layer string) error {
+	_, statErr := os.Stat(document.Path)
+
+	issues, err := document.Save()
+	printConfigIssues(issues)
+	if err != nil {
+		var validationErr *_config.ValidationError
+		if errors.As(err, &validationErr) {
+			return errors.New("not saved: the change would make the configuration invalid")
+		}
+		return err
+	}
+
+	if os.IsNotExist(statErr) {
+		return excludeLocalConfig(git, layer)
+	}
+	return nil
+}
+
+// excludeLocalConfig adds the local config file to .git/info/exclude after it was created
+func excludeLocalConfig(git *git.Git, layer string) error {
+	if layer != _config.LayerLocal {
+		return nil
+	}
+	if err := git.AddExclude("/" + _config.LocalConfigFileName); err != nil {
+		return fmt.Errorf("failed to add %s to .git/info/exclude: %w", _config.LocalConfigFileName, err)
+	}
+	return nil
+}
+
+// printConfigIssues prints warnings and errors and returns the number of errors
+func printConfigIssues(issues []_config.Issue) int {
+	orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+	redStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.Warning {
+			fmt.Printf("%s %s\n", orangeStyle.Render("warning:"), issue)
+		} else {
+			errorCount++
+			fmt.Printf("%s %s\n", redStyle.Render("error:"), issue)
+		}
+	}
+	return errorCount
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"os"
+	"os/exec"
+)
+
+func ConfigEdit(git *git.Git) *cobra.Command {
+	var layerFlags configLayerFlags
+
+	editCmd := &cobra.Command{
+		Use:   "edit",
+		Short: "Open a configuration file in your editor",
+		Long: `Open the repository config file, or the file selected with a layer flag, in $VISUAL or $EDITOR.
+
+The edited file is validated before it is saved. If it has errors you can edit it again or discard the changes.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, layer, err := layerFlags.path(git)
+			if err != nil {
+				return err
+			}
+
+			original, err := os.ReadFile(path)
+			existed := err == nil
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			// Edit a copy so an invalid file never replaces the original
+			tempFile, err := os.CreateTemp("", "gwt-config-*.yml")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tempFile.Name())
+			if _, err := tempFile.Write(original); err != nil {
+				return err
+			}
+			if err := tempFile.Close(); err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			for {
+				if err := runEditor(tempFile.Name()); err != nil {
+					return err
+				}
+				content, err := os.ReadFile(tempFile.Name())
+				if err != nil {
+					return err
+				}
+
+				issues, err := _config.ValidateFile(path, content)
+				if err != nil {
+					fmt.Println(err)
+				}
+				if printConfigIssues(issues) == 0 && err == nil {
+					if string(content) == string(original) {
+						fmt.Println("No changes.")
+						return nil
+					}
+					if err := os.WriteFile(path, content, 0644); err != nil {
+						return err
+					}
+					if !existed {
+						if err := excludeLocalConfig(git, layer); err != nil {
+							return err
+						}
+					}
+					faintStyle := lipgloss.NewStyle().Faint(true)
+					fmt.Printf("Saved %s\n", faintStyle.Render(path))
+					return nil
+				}
+
+				var editAgain bool
+				err = huh.NewConfirm().
+					Title("The configuration is invalid. Do you want to edit it again?").
+					Affirmative("Yes").
+					Negative("No, discard changes").
+					Value(&editAgain).
+					Run()
+				if err != nil {
+					return err
+				}
+				if !editAgain {
+					return errors.New("changes discarded")
+				}
+			}
+		},
+	}
+
+	layerFlags.register(editCmd)
+
+	return editCmd
+}
+
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	editorCmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	editorCmd.Stdin = os.Stdin
+	editorCmd.Stdout = os.Stdout
+	editorCmd.Stderr = os.Stderr
+	if err := editorCmd.Run(); err != nil {
+		return fmt.Errorf("editor '%s' failed: %w", editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"strconv"
+	"strings"
+)
+
+func ConfigExplain(git *git.Git) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain <branch>",
+		Short: "Show which rules match a branch and the settings that apply to it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+			config, origins, err := _config.LoadConfigWithOrigins(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			branch := strings.TrimPrefix(args[0], "origin/")
+			branchConfig := config.ForBranch(branch)
+
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+
+			fmt.Printf("%s %s\n\n", boldStyle.Render("Branch"), branch)
+
+			fmt.Println(boldStyle.Render("Rules"))
+			if len(config.Rules) == 0 {
+				fmt.Println(faintStyle.Render("  No rules configured"))
+			}
+			for i, rule := range config.Rules {
+				key := fmt.Sprintf("rules[%d]", i)
+				location := ""
+				for _, field := range []string{"match", "regex"} {
+					if origin, ok := origins[key+"."+field]; ok {
+						location = fmt.Sprintf("%s:%d", origin.Path, origin.Line)
+					}
+				}
+				marker, label := "  ", faintStyle.Render("no match")
+				for _, matched := range branchConfig.Matched {
+					if matched == i {
+						marker, label = greenStyle.Render("✓ "), greenStyle.Render("matched")
+					}
+				}
+				fmt.Printf("%s%s %s %s %s\n", marker, key, rule.Pattern(), label, faintStyle.Render(location))
+			}
+			fmt.Println()
+
+			worktreePath, err := branchConfig.ResolveWorktreePath(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(boldStyle.Render("Settings"))
+			type setting struct {
+				key   string
+				value string
+			}
+			settings := []setting{
+				{"base_branch", branchConfig.BaseBranch},
+				{"worktree_path", worktreePath},
+				{"open", formatList(branchConfig.Open)},
+				{"init_commands", formatList(branchConfig.InitCommands)},
+				{"destroy_commands", formatList(branchConfig.DestroyCommands)},
+				{"copy", formatList(branchConfig.Copy)},
+				{"symlink", formatList(branchConfig.Symlink)},
+			}
+			for _, event := range _config.HookEvents {
+				var commands []string
+				for _, hook := range *branchConfig.Hooks.Event(event) {
+					commands = append(commands, hook.Run)
+				}
+				settings = append(settings, setting{"hooks." + event, formatList(commands)})
+			}
+			jobs := "number of CPUs"
+			if branchConfig.Hooks.Jobs > 0 {
+				jobs = strconv.Itoa(branchConfig.Hooks.Jobs)
+			}
+			notify := branchConfig.Hooks.Notify
+			if notify == "" {
+				notify = `""`
+			}
+			settings = append(settings, setting{"hooks.jobs", jobs}, setting{"hooks.notify", notify})
+			for _, setting := range settings {
+				fmt.Printf("  %s: %s %s\n", setting.key, setting.value, faintStyle.Render("# "+branchConfig.Sources[setting.key]))
+			}
+			return nil
+		},
+	}
+}
+
+func formatList(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func ConfigGet(git *git.Git) *cobra.Command {
+	var layerFlags configLayerFlags
+
+	getCmd := &cobra.Command{
+		Use:   "get <key>",
+		Short: "Print a configuration value",
+		Long: `Print a configuration value, e.g. "gwt config get defaults.base_branch" or "gwt config get init_commands[0]".
+
+Without a layer flag the effective value from the merged configuration is printed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var value *yaml.Node
+			if layerFlags.selected() {
+				path, _, err := layerFlags.path(git)
+				if err != nil {
+					return err
+				}
+				document, err := _config.OpenDocument(path)
+				if err != nil {
+					return err
+				}
+				if value, err = document.Get(args[0]); err != nil {
+					cmd.SilenceUsage = true
+					return err
+				}
+			} else {
+				var repoRoot string
+				if err := git.SetWorktreeRoot(); err == nil {
+					repoRoot = git.GetWorktreeRoot()
+				}
+				config, err := _config.LoadConfig(repoRoot)
+				if err != nil {
+					return err
+				}
+				if value, err = _config.LookupEffective(config, args[0]); err != nil {
+					cmd.SilenceUsage = true
+					return err
+				}
+			}
+
+			output, err := _config.EncodeValue(value)
+			if err != nil {
+				return err
+			}
+			fmt.Println(output)
+			return nil
+		},
+	}
+
+	layerFlags.register(getCmd)
+
+	return getCmd
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"encoding/json"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func ConfigSchema() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the configuration file",
+		Long: `Print the JSON Schema of the configuration file for editor autocompletion and validation.
+
+For example, with the YAML language server:
+  gwt config schema > ~/.config/gwt/schema.json
+and add "# yaml-language-server: $schema=~/.config/gwt/schema.json" to the top of .gwt.yml.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(_config.JSONSchema())
+		},
+	}
+}
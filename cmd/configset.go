@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func ConfigSet(git *git.Git) *cobra.Command {
+	var layerFlags configLayerFlags
+
+	setCmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Set a configuration value",
+		Long: `Set a configuration value in the repository config file, or the file selected with a layer flag.
+
+The value is parsed as YAML unless the key holds a string, so lists can be set with "[a, b]". Use an index equal
+to the length of a list to append to it, e.g. "gwt config set init_commands[3] 'make setup'". Comments and the order
+of keys in the file are kept, and the file is only written if the result is valid.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, layer, err := layerFlags.path(git)
+			if err != nil {
+				return err
+			}
+			document, err := _config.OpenDocument(path)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			if err := document.Set(args[0], args[1]); err != nil {
+				return err
+			}
+			if err := saveConfigDocument(git, document, layer); err != nil {
+				return err
+			}
+
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			fmt.Printf("Set %s %s\n", args[0], faintStyle.Render("in "+path))
+			return nil
+		},
+	}
+
+	layerFlags.register(setCmd)
+
+	return setCmd
+}
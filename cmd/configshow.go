@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func ConfigShow(git *git.Git) *cobra.Command {
+	var showOrigin bool
+
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the effective configuration",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Outside a repository only the global and --config layers apply
+			var repoRoot string
+			if err := git.SetWorktreeRoot(); err == nil {
+				repoRoot = git.GetWorktreeRoot()
+			}
+
+			config, origins, err := _config.LoadConfigWithOrigins(repoRoot)
+			if err != nil {
+				return err
+			}
+
+			if !showOrigin {
+				output, err := yaml.Marshal(config)
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(output))
+				return nil
+			}
+
+			keys, values, err := _config.FlattenConfig(config)
+			if err != nil {
+				return err
+			}
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			for _, key := range keys {
+				origin := "default"
+				if o, ok := origins[key]; ok {
+					origin = o.String()
+				}
+				fmt.Printf("%s: %s %s\n", key, values[key], faintStyle.Render("# "+origin))
+			}
+			return nil
+		},
+	}
+
+	showCmd.Flags().BoolVar(&showOrigin, "origin", false, "Show the file and line each value comes from")
+
+	return showCmd
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func ConfigUnset(git *git.Git) *cobra.Command {
+	var layerFlags configLayerFlags
+
+	unsetCmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "Remove a configuration value",
+		Long: `Remove a configuration value from the repository config file, or the file selected with a layer flag.
+
+Removing a list item, e.g. "gwt config unset init_commands[1]", shifts the items after it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path, layer, err := layerFlags.path(git)
+			if err != nil {
+				return err
+			}
+			document, err := _config.OpenDocument(path)
+			if err != nil {
+				return err
+			}
+
+			cmd.SilenceUsage = true
+			if err := document.Unset(args[0]); err != nil {
+				return err
+			}
+			if err := saveConfigDocument(git, document, layer); err != nil {
+				return err
+			}
+
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			fmt.Printf("Unset %s %s\n", args[0], faintStyle.Render("in "+path))
+			return nil
+		},
+	}
+
+	layerFlags.register(unsetCmd)
+
+	return unsetCmd
+}
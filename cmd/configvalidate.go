@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+)
+
+func ConfigValidate(git *git.Git) *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the configuration files for errors",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var repoRoot string
+			if err := git.SetWorktreeRoot(); err == nil {
+				repoRoot = git.GetWorktreeRoot()
+			}
+
+			issues, err := _config.ValidateConfig(repoRoot)
+			if err != nil {
+				return err
+			}
+
+			errorCount := printConfigIssues(issues)
+			if errorCount > 0 {
+				cmd.SilenceUsage = true
+				return errors.New("configuration is invalid")
+			}
+			greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+			fmt.Println(greenStyle.Render("Configuration is valid."))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	_git "github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"strings"
+)
+
+// commandContext describes a worktree to the commands that run for it
+func commandContext(git *_git.Git, event string, worktreePath string, name string, branch string, baseBranch string) utils.CommandContext {
+	return utils.CommandContext{
+		Event:      event,
+		Path:       worktreePath,
+		Name:       name,
+		Branch:     branch,
+		BaseBranch: baseBranch,
+		RepoRoot:   strings.TrimSuffix(git.GetWorktreeRoot(), "/"),
+		RepoName:   git.GetRepoName(),
+	}
+}
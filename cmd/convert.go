@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/spf13/cobra"
+	"os"
+	"path/filepath"
+)
+
+func Convert(git *git.Git, nav *navigator.Navigator) *cobra.Command {
+	var undo bool
+
+	convertCmd := &cobra.Command{
+		Use:   "convert [dir]",
+		Short: "Convert a regular clone into the gwt worktree layout",
+		Long: `Convert a regular clone into the gwt worktree layout in place.
+
+The .git directory is moved to .bare and the files of the current branch, including uncommitted and untracked
+changes, are moved into the worktree at the path worktree_path gives the branch. Linked worktrees inside the
+repository and the .gwt.yml and .gwt.local.yml files stay in the root, and tracked config files are copied into the
+worktree too. Existing linked worktrees are repaired to point at the new location. Run with --undo to restore the
+original clone.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var dir string
+			if len(args) == 1 {
+				dir = args[0]
+			} else {
+				if err := git.SetWorktreeRoot(); err != nil {
+					return err
+				}
+				dir = git.GetWorktreeRoot()
+			}
+			root, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+
+			cwd, _ := os.Getwd()
+			if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+				cwd = resolved
+			}
+			if resolved, err := filepath.EvalSymlinks(root); err == nil {
+				root = resolved
+			}
+
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			var targetDir string
+			if undo {
+				targetDir, err = git.UndoConvert(root)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Restored the regular clone at %s.\n", boldStyle.Render(targetDir))
+			} else {
+				targetDir, err = git.ConvertRepo(root)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Converted %s. The current branch now lives in %s.\n", boldStyle.Render(root), boldStyle.Render(targetDir))
+				fmt.Printf("Run 'gwt convert --undo %s' to revert.\n", root)
+			}
+
+			if nav.Enabled() && navigator.IsWithin(cwd, root) {
+				if relDir, err := filepath.Rel(root, cwd); err == nil {
+					candidate := filepath.Join(targetDir, relDir)
+					if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+						targetDir = candidate
+					}
+				}
+				return nav.ChangeDir(targetDir)
+			}
+			return nil
+		},
+	}
+
+	convertCmd.Flags().BoolVar(&undo, "undo", false, "Revert a previous conversion")
+
+	return convertCmd
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/files"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/utils"
+)
+
+// copyWorktreeFiles copies and symlinks the configured files from the source worktree into the new worktree and prints
+// what was done. The source is copy_from, or the worktree of the base branch.
+func copyWorktreeFiles(git *_git.Git, config *_config.Config, branchConfig *_config.BranchConfig, worktreePath string) error {
+	if len(branchConfig.Copy) == 0 && len(branchConfig.Symlink) == 0 {
+		return nil
+	}
+
+	source := config.Defaults.CopyFrom
+	if source == "" {
+		source = branchConfig.BaseBranch
+		if source == _config.LatestTagBaseBranch {
+			source = config.Defaults.BaseBranch
+		}
+	}
+
+	sourceWorktree, err := git.GetWorktree(source)
+	if errors.Is(err, _git.ErrWorktreeNotFound) {
+		fmt.Println(orangeStyle.Render(fmt.Sprintf("No worktree for '%s' to copy files from. Set defaults.copy_from to use another one.", source)))
+		fmt.Println()
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if sourceWorktree.Path == worktreePath {
+		return nil
+	}
+
+	maxSize, err := _config.ParseSize(config.Defaults.CopyMaxSize)
+	if err != nil {
+		return err
+	}
+	report, err := files.Apply(files.Options{
+		Source:  sourceWorktree.Path,
+		Target:  worktreePath,
+		Copy:    branchConfig.Copy,
+		Symlink: branchConfig.Symlink,
+		Mode:    files.Mode(config.Defaults.CopyMode),
+		MaxSize: maxSize,
+		DryRun:  utils.DryRun(),
+	})
+	if report != nil {
+		printCopyReport(report, sourceWorktree.Name)
+	}
+	return err
+}
+
+func printCopyReport(report *files.Report, source string) {
+	if len(report.Entries) == 0 {
+		fmt.Println(faintStyle.Render(fmt.Sprintf("No files to copy from worktree %s.", source)))
+		fmt.Println()
+		return
+	}
+
+	title := fmt.Sprintf("Files from worktree %s:", source)
+	if utils.DryRun() {
+		title = fmt.Sprintf("Files that would be copied from worktree %s:", source)
+	}
+	fmt.Println(boldStyle.Render(title))
+	for _, entry := range report.Entries {
+		switch entry.Action {
+		case files.ActionExists:
+			fmt.Printf("  %s %s %s\n", faintStyle.Render("-"), entry.Path, faintStyle.Render("already exists"))
+		case files.ActionSkipped:
+			fmt.Printf("  %s %s %s\n", orangeStyle.Render("!"), entry.Path,
+				orangeStyle.Render(fmt.Sprintf("skipped (%s): %s", formatSize(entry.Size), entry.Reason)))
+		case files.ActionSymlinked:
+			fmt.Printf("  %s %s %s\n", greenStyle.Render("✓"), entry.Path, faintStyle.Render("symlinked"))
+		default:
+			fmt.Printf("  %s %s %s\n", greenStyle.Render("✓"), entry.Path,
+				faintStyle.Render(fmt.Sprintf("%s, %s", entry.Action, formatSize(entry.Size))))
+		}
+	}
+	fmt.Println()
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exponent := float64(size)/unit, 0
+	for value >= unit && exponent < 3 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %cB", value, "KMGT"[exponent])
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+	"path/filepath"
+	"strings"
+)
+
+func Hooks(git *git.Git, selecter *selecter.Select) *cobra.Command {
+	hooksCmd := &cobra.Command{
+		Use:   "hooks",
+		Short: "List and run lifecycle hooks",
+		Long: `List and run lifecycle hooks.
+
+Hooks are configured per event under hooks, and rules can replace the hooks of an event for matching branches:
+  ` + strings.Join(_config.HookEvents, ", ") + `
+
+Each event takes a command, a mapping, or a list of them. A mapping sets run and optionally dir, env, if (exists
+and branch), timeout and continue_on_error, e.g.
+
+  hooks:
+    post_add:
+      - run: npm ci
+        if:
+          exists: package.json
+        timeout: 10m
+      - make setup
+
+Hooks of post_add and post_clone with background: true run in a detached process once the other hooks have
+finished, and gwt list shows the worktree as setting up until they are done. hooks.notify runs when they finish.
+
+The output of every run is kept in a log of the worktree, shown by gwt logs.
+
+Hooks from the repository's .gwt.yml only run once you have trusted them with gwt trust.`,
+	}
+
+	hooksCmd.AddCommand(HooksList(git))
+	hooksCmd.AddCommand(HooksRun(git, selecter))
+	hooksCmd.AddCommand(HooksBackground())
+
+	return hooksCmd
+}
+
+// currentWorktree returns the worktree containing the working directory, or nil outside of a worktree
+func currentWorktree(git *git.Git) (*git.Worktree, error) {
+	currentPath, err := git.GetCurrentWorktreePath()
+	if err != nil {
+		return nil, nil
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+	for _, wt := range worktrees {
+		if filepath.Clean(wt.Path) == filepath.Clean(currentPath) {
+			return &wt, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+// HooksBackground runs background hooks in the detached process gwt add and gwt clone start for them
+func HooksBackground() *cobra.Command {
+	return &cobra.Command{
+		Use:    "__background <state-file>",
+		Short:  "Run background hooks recorded in a state file",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return _hooks.RunBackground(args[0])
+		},
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/spf13/cobra"
+	"slices"
+	"strings"
+)
+
+func HooksList(git *_git.Git) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list [worktree]",
+		Short: "List the hooks of every event",
+		Long: `List the hooks of every event. With a worktree, or inside one, the hooks of rules matching its branch are
+listed instead of the ones they replace.`,
+		Aliases: []string{"ls"},
+		Args:    cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeWorktrees(git, args, func(_git.Worktree) bool { return true })
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			var wt *_git.Worktree
+			if len(args) > 0 {
+				if wt, err = git.GetWorktree(args[0]); err != nil {
+					return fmt.Errorf("%w: %s", err, args[0])
+				}
+			} else if wt, err = currentWorktree(git); err != nil {
+				return err
+			}
+			branch := ""
+			if wt != nil {
+				branch = wt.BranchName()
+			}
+			branchConfig := config.ForBranch(branch)
+
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			if wt != nil {
+				fmt.Printf("%s %s\n", boldStyle.Render("Worktree"), wt.Name)
+				setup, err := _hooks.LoadSetup(git.GetWorktreeRoot(), wt.Name)
+				if err != nil {
+					return err
+				}
+				if setup != nil {
+					fmt.Printf("%s %s", boldStyle.Render("Background hooks"), formatSetup(string(setup.Status)))
+					if setup.Error != "" {
+						fmt.Print(faintStyle.Render(": " + setup.Error))
+					}
+					fmt.Println()
+				}
+				fmt.Println()
+			}
+			for i, event := range _config.HookEvents {
+				fmt.Printf("%s %s\n", boldStyle.Render(event), faintStyle.Render("# "+branchConfig.Sources["hooks."+event]))
+				hooks := *branchConfig.Hooks.Event(event)
+				if len(hooks) == 0 {
+					fmt.Println(faintStyle.Render("  No hooks"))
+				}
+				for j, hook := range hooks {
+					fmt.Printf("  %d. %s%s\n", j+1, hook.Run, faintStyle.Render(formatHookSettings(hook)))
+				}
+				if i < len(_config.HookEvents)-1 {
+					fmt.Println()
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// formatHookSettings renders the settings of a hook besides its command, e.g. "  dir: web  timeout: 5m"
+func formatHookSettings(hook _config.Hook) string {
+	var settings []string
+	if hook.Name != "" {
+		settings = append(settings, "name: "+hook.Name)
+	}
+	if hook.Background {
+		settings = append(settings, "background")
+	}
+	if hook.Parallel {
+		settings = append(settings, "parallel")
+	}
+	if hook.Needs != nil {
+		settings = append(settings, "needs: "+strings.Join(hook.Needs, ", "))
+	}
+	if hook.Dir != "" {
+		settings = append(settings, "dir: "+hook.Dir)
+	}
+	if len(hook.Env) > 0 {
+		names := make([]string, 0, len(hook.Env))
+		for name := range hook.Env {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		settings = append(settings, "env: "+strings.Join(names, ", "))
+	}
+	if hook.If.Exists != "" {
+		settings = append(settings, "if exists: "+hook.If.Exists)
+	}
+	if hook.If.Branch != "" {
+		settings = append(settings, "if branch: "+hook.If.Branch)
+	}
+	if hook.Timeout != "" {
+		settings = append(settings, "timeout: "+hook.Timeout)
+	}
+	if hook.ContinueOnError {
+		settings = append(settings, "continue on error")
+	}
+	if len(settings) == 0 {
+		return ""
+	}
+	return "  " + strings.Join(settings, "  ")
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+	"os"
+	"slices"
+	"strings"
+)
+
+func HooksRun(git *_git.Git, selecter *selecter.Select) *cobra.Command {
+	var jobs int
+
+	hooksRunCmd := &cobra.Command{
+		Use:   "run <event> [worktree]",
+		Short: "Run the hooks of an event in an existing worktree",
+		Long: `Run the hooks of an event in an existing worktree, e.g. "gwt hooks run post_add" to set a worktree up again.
+Without a worktree the hooks run in the current worktree, or in the one you select outside of a worktree.`,
+		Args: cobra.RangeArgs(1, 2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) == 0 {
+				return _config.HookEvents, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeWorktrees(git, args[1:], func(_git.Worktree) bool { return true })
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			event := args[0]
+			if !slices.Contains(_config.HookEvents, event) {
+				return fmt.Errorf("unknown event '%s'. Valid events: %s", event, strings.Join(_config.HookEvents, ", "))
+			}
+
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			var wt *_git.Worktree
+			if len(args) > 1 {
+				if wt, err = git.GetWorktree(args[1]); err != nil {
+					return fmt.Errorf("%w: %s", err, args[1])
+				}
+			} else if wt, err = currentWorktree(git); err != nil {
+				return err
+			}
+			if wt == nil {
+				worktrees, err := git.ListWorktrees()
+				if err != nil {
+					return err
+				}
+				if len(worktrees) == 0 {
+					return errors.New("no worktrees to run hooks in")
+				}
+				worktree, err := selectWorktree(selecter, fmt.Sprintf("Select a worktree to run the %s hooks in:", event), worktrees)
+				if err != nil {
+					return err
+				}
+				if worktree == "" {
+					return nil
+				}
+				if wt, err = git.GetWorktree(worktree); err != nil {
+					return err
+				}
+			}
+
+			branchConfig := config.ForBranch(wt.BranchName())
+			hooks := *branchConfig.Hooks.Event(event)
+			if len(hooks) == 0 {
+				boldStyle := lipgloss.NewStyle().Bold(true)
+				fmt.Printf("No %s hooks configured for worktree %s.\n", event, boldStyle.Render(wt.Name))
+				return nil
+			}
+			cmd.SilenceUsage = true
+			if err := ensureTrusted(git, os.Stdout); err != nil {
+				return err
+			}
+			baseBranch, err := git.ResolveBaseBranch(config, branchConfig.BaseBranch)
+			if err != nil {
+				baseBranch = branchConfig.BaseBranch
+			}
+			hookCtx := commandContext(git, event, wt.Path, wt.Name, wt.BranchName(), baseBranch)
+			if jobs == 0 {
+				jobs = branchConfig.Hooks.Jobs
+			}
+			return _hooks.Run(hooks, hookCtx, os.Stdout, jobs)
+		},
+	}
+
+	hooksRunCmd.Flags().IntVarP(&jobs, "jobs", "j", 0, "Number of parallel hooks run at the same time. Defaults to hooks.jobs, or the number of CPUs")
+
+	return hooksRunCmd
+}
@@ -3,7 +3,9 @@ package cmd
 import (
 	"fmt"
 	"github.com/charmbracelet/huh"
+	_config "github.com/jcelaya775/gwt/internal/config"
 	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/trust"
 	"github.com/spf13/cobra"
 	"os"
 	"path/filepath"
@@ -18,7 +20,7 @@ func Init(git *git.Git) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			configPath := filepath.Join(git.GetWorktreeRoot(), ".gwt.yml")
+			configPath := filepath.Join(git.GetWorktreeRoot(), _config.ConfigFileName)
 			if _, err := os.Stat(configPath); err == nil {
 				var confirm bool
 				err := huh.NewConfirm().
@@ -36,7 +38,7 @@ func Init(git *git.Git) *cobra.Command {
 			}
 
 			configContent := `# gwt configuration
-version: "1.0"
+version: "` + _config.CurrentVersion + `"
 
 # Default settings for worktrees
 defaults:
@@ -48,12 +50,20 @@ init_commands:
   - echo "Worktree initialized!"
 
 # Commands that run when removing a worktree
-remove_commands:
+destroy_commands:
   - echo "Worktree removed!"
 `
 			if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
 				return err
 			}
+			// The commands were just written by the user, so they do not need to be trusted first
+			status, err := trustStatus(git)
+			if err != nil {
+				return err
+			}
+			if err := trust.Trust(git.GetWorktreeRoot(), status.Commands); err != nil {
+				return err
+			}
 
 			fmt.Println("Initialized gwt configuration at", configPath)
 			return nil
@@ -1,20 +1,59 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"github.com/charmbracelet/lipgloss/tree"
 	"github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
 	"github.com/spf13/cobra"
-	"path/filepath"
+	"os"
+	"path"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 )
 
+// listEntry is the data exposed by the list output formats and templates
+type listEntry struct {
+	Name         string    `json:"name"`
+	Path         string    `json:"path"`
+	Branch       string    `json:"branch"`
+	Head         string    `json:"head"`
+	Detached     bool      `json:"detached"`
+	Dirty        bool      `json:"dirty"`
+	Locked       bool      `json:"locked"`
+	LockedReason string    `json:"locked_reason,omitempty"`
+	Created      time.Time `json:"created"`
+	Setup        string    `json:"setup,omitempty"` // Progress of background hooks
+}
+
+var listSortKeys = []string{"name", "branch", "created", "path"}
+
 func List(git *git.Git) *cobra.Command {
 	var absolutePath bool
+	var format string
+	var sortKey string
+	var treeView bool
+	var onlyDirty bool
+	var onlyLocked bool
+	var branchGlob string
+	var jobs int
 
 	var listCmd = &cobra.Command{
 		Use:     "list",
 		Short:   "List all worktrees",
 		Aliases: []string{"ls"},
-		Args:    cobra.NoArgs,
+		Long: `List all worktrees.
+
+--format accepts json, tsv, table or a Go template such as '{{.Branch}} {{.Path}}'.
+Templates can use .Name, .Path, .Branch, .Head, .Detached, .Dirty, .Locked, .LockedReason, .Created and .Setup.
+Setup is the progress of background hooks: setting up, ready or failed.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			err := git.SetWorktreeRoot()
 			if err != nil {
@@ -25,11 +64,71 @@ func List(git *git.Git) *cobra.Command {
 				return err
 			}
 
-			for _, wt := range worktrees {
-				if absolutePath {
-					fmt.Println(filepath.Join(git.GetWorktreeRoot(), wt))
-				} else {
-					fmt.Println(wt)
+			var tmpl *template.Template
+			switch format {
+			case "", "json", "tsv", "table":
+			default:
+				tmpl, err = template.New("format").Parse(format)
+				if err != nil {
+					return fmt.Errorf("invalid --format template: %w", err)
+				}
+			}
+
+			needsDirty := onlyDirty || format == "json" || format == "tsv" || format == "table" ||
+				(tmpl != nil && strings.Contains(format, ".Dirty"))
+			entries, err := buildListEntries(worktrees, needsDirty, jobs)
+			if err != nil {
+				return err
+			}
+			for i := range entries {
+				setup, err := _hooks.LoadSetup(git.GetWorktreeRoot(), entries[i].Name)
+				if err != nil {
+					return err
+				}
+				if setup != nil {
+					entries[i].Setup = string(setup.Status)
+				}
+			}
+
+			entries, err = filterListEntries(entries, onlyDirty, onlyLocked, branchGlob)
+			if err != nil {
+				return err
+			}
+			if err := sortListEntries(entries, sortKey); err != nil {
+				return err
+			}
+
+			if treeView {
+				fmt.Println(buildListTree(git.GetRepoName(), entries, absolutePath))
+				return nil
+			}
+
+			switch format {
+			case "":
+				for _, entry := range entries {
+					if absolutePath {
+						fmt.Println(entry.Path)
+					} else {
+						fmt.Println(entry.Name)
+					}
+				}
+			case "json":
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(entries)
+			case "tsv":
+				for _, entry := range entries {
+					fmt.Printf("%s\t%s\t%s\t%t\t%t\t%s\t%s\t%s\n", entry.Name, entry.Branch, entry.Head, entry.Dirty,
+						entry.Locked, entry.Created.Format(time.RFC3339), entry.Path, entry.Setup)
+				}
+			case "table":
+				printListTable(entries, absolutePath)
+			default:
+				for _, entry := range entries {
+					if err := tmpl.Execute(os.Stdout, entry); err != nil {
+						return err
+					}
+					fmt.Println()
 				}
 			}
 
@@ -38,6 +137,214 @@ func List(git *git.Git) *cobra.Command {
 	}
 
 	listCmd.Flags().BoolVarP(&absolutePath, "absolute", "a", false, "Show absolute paths")
+	listCmd.Flags().StringVar(&format, "format", "", "Output format: json, tsv, table or a Go template")
+	listCmd.Flags().StringVar(&sortKey, "sort", "name", "Sort by name, branch, created or path. Prefix with - to reverse")
+	listCmd.Flags().BoolVar(&treeView, "tree", false, "Group worktrees by slash-separated branch names")
+	listCmd.Flags().BoolVar(&onlyDirty, "dirty", false, "Only show worktrees with uncommitted changes")
+	listCmd.Flags().BoolVar(&onlyLocked, "locked", false, "Only show locked worktrees")
+	listCmd.Flags().StringVar(&branchGlob, "branch-glob", "", "Only show worktrees whose branch matches the glob, e.g. 'feature/*'")
+	listCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of worktrees to query concurrently")
+	_ = listCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions([]string{"json", "tsv", "table"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = listCmd.RegisterFlagCompletionFunc("sort", cobra.FixedCompletions(listSortKeys, cobra.ShellCompDirectiveNoFileComp))
 
 	return listCmd
 }
+
+func buildListEntries(worktrees []git.Worktree, withDirty bool, jobs int) ([]listEntry, error) {
+	entries := make([]listEntry, len(worktrees))
+	for i, wt := range worktrees {
+		created, _ := wt.CreatedTime()
+		entries[i] = listEntry{
+			Name:         wt.Name,
+			Path:         wt.Path,
+			Branch:       wt.BranchName(),
+			Head:         wt.Head,
+			Detached:     wt.Detached,
+			Locked:       wt.Locked,
+			LockedReason: wt.LockedReason,
+			Created:      created,
+		}
+	}
+	if !withDirty {
+		return entries, nil
+	}
+
+	dirty, err := git.CheckDirty(worktrees, jobs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Dirty = dirty[i]
+	}
+	return entries, nil
+}
+
+func filterListEntries(entries []listEntry, onlyDirty, onlyLocked bool, branchGlob string) ([]listEntry, error) {
+	if branchGlob != "" {
+		if _, err := path.Match(branchGlob, ""); err != nil {
+			return nil, fmt.Errorf("invalid --branch-glob: %w", err)
+		}
+	}
+
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if onlyDirty && !entry.Dirty {
+			continue
+		}
+		if onlyLocked && !entry.Locked {
+			continue
+		}
+		if branchGlob != "" {
+			if matched, _ := path.Match(branchGlob, entry.Branch); !matched {
+				continue
+			}
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered, nil
+}
+
+func sortListEntries(entries []listEntry, sortKey string) error {
+	key, reverse := strings.CutPrefix(sortKey, "-")
+	var compare func(a, b listEntry) int
+	switch key {
+	case "name":
+		compare = func(a, b listEntry) int { return strings.Compare(a.Name, b.Name) }
+	case "branch":
+		compare = func(a, b listEntry) int { return strings.Compare(a.Branch, b.Branch) }
+	case "created":
+		compare = func(a, b listEntry) int { return a.Created.Compare(b.Created) }
+	case "path":
+		compare = func(a, b listEntry) int { return strings.Compare(a.Path, b.Path) }
+	default:
+		return fmt.Errorf("invalid --sort key '%s'. Valid keys: %s", sortKey, strings.Join(listSortKeys, ", "))
+	}
+
+	slices.SortStableFunc(entries, func(a, b listEntry) int {
+		if reverse {
+			return compare(b, a)
+		}
+		return compare(a, b)
+	})
+	return nil
+}
+
+func printListTable(entries []listEntry, absolutePath bool) {
+	hasSetup := slices.ContainsFunc(entries, func(entry listEntry) bool { return entry.Setup != "" })
+
+	header := "WORKTREE"
+	if absolutePath {
+		header = "PATH"
+	}
+
+	rows := make([][]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name
+		if absolutePath {
+			name = entry.Path
+		}
+		branch := entry.Branch
+		if entry.Detached {
+			branch = faintStyle.Render("(detached)")
+		}
+		dirty := ""
+		if entry.Dirty {
+			dirty = orangeStyle.Render("dirty")
+		}
+		locked := ""
+		if entry.Locked {
+			locked = orangeStyle.Render("locked")
+			if entry.LockedReason != "" {
+				locked += faintStyle.Render(": " + entry.LockedReason)
+			}
+		}
+		created := ""
+		if !entry.Created.IsZero() {
+			created = formatAge(entry.Created)
+		}
+		row := []string{name, branch, shortSha(entry.Head), dirty, locked, created}
+		if hasSetup {
+			row = append(row, formatSetup(entry.Setup))
+		}
+		rows = append(rows, row)
+	}
+	headers := []string{header, "BRANCH", "HEAD", "DIRTY", "LOCKED", "CREATED"}
+	if hasSetup {
+		headers = append(headers, "SETUP")
+	}
+
+	t := table.New().
+		Border(lipgloss.HiddenBorder()).
+		BorderTop(false).
+		BorderBottom(false).
+		BorderHeader(false).
+		Headers(headers...).
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return boldStyle
+			}
+			return lipgloss.NewStyle()
+		})
+	fmt.Println(t)
+}
+
+// buildListTree groups worktrees by the slash-separated segments of their branch (or name when detached)
+func buildListTree(root string, entries []listEntry, absolutePath bool) *tree.Tree {
+	t := tree.Root(root)
+	groups := map[string]*tree.Tree{"": t}
+
+	var groupFor func(prefix string) *tree.Tree
+	groupFor = func(prefix string) *tree.Tree {
+		if group, ok := groups[prefix]; ok {
+			return group
+		}
+		parentPrefix, name := "", prefix
+		if i := strings.LastIndex(prefix, "/"); i >= 0 {
+			parentPrefix, name = prefix[:i], prefix[i+1:]
+		}
+		group := tree.Root(name + "/")
+		groupFor(parentPrefix).Child(group)
+		groups[prefix] = group
+		return group
+	}
+
+	for _, entry := range entries {
+		key := entry.Branch
+		if key == "" {
+			key = entry.Name
+		}
+		prefix, leaf := "", key
+		if i := strings.LastIndex(key, "/"); i >= 0 {
+			prefix, leaf = key[:i], key[i+1:]
+		}
+		label := leaf
+		if entry.Locked {
+			label += " " + faintStyle.Render("(locked)")
+		}
+		if entry.Setup != "" {
+			label += " " + formatSetup(entry.Setup)
+		}
+		if absolutePath {
+			label += " " + faintStyle.Render(entry.Path)
+		} else if entry.Name != key {
+			label += " " + faintStyle.Render(entry.Name)
+		}
+		groupFor(prefix).Child(label)
+	}
+
+	return t
+}
+
+// formatSetup colours the progress of a worktree's background hooks
+func formatSetup(setup string) string {
+	switch _hooks.SetupStatus(setup) {
+	case _hooks.SetupRunning:
+		return orangeStyle.Render(setup)
+	case _hooks.SetupReady:
+		return greenStyle.Render(setup)
+	case _hooks.SetupFailed:
+		return redStyle.Render(setup)
+	}
+	return setup
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+)
+
+func Lock(git *git.Git, selecter *selecter.Select) *cobra.Command {
+	var reason string
+
+	lockCmd := &cobra.Command{
+		Use:   "lock [worktree]",
+		Short: "Lock a worktree so it is not removed or pruned",
+		Args:  cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeWorktrees(git, args, isUnlocked)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			var worktree string
+			if len(args) == 0 {
+				worktrees, err := git.ListWorktrees()
+				if err != nil {
+					return err
+				}
+				unlocked := worktrees[:0]
+				for _, wt := range worktrees {
+					if !wt.Locked {
+						unlocked = append(unlocked, wt)
+					}
+				}
+				if len(unlocked) == 0 {
+					return errors.New("no unlocked worktrees to lock")
+				}
+				worktree, err = selectWorktree(selecter, "Select a worktree to lock:", unlocked)
+				if err != nil {
+					return err
+				}
+				if worktree == "" {
+					return nil
+				}
+			} else {
+				worktree = args[0]
+			}
+
+			if err := git.LockWorktree(worktree, reason); err != nil {
+				return err
+			}
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			fmt.Printf("Worktree %s locked.\n", boldStyle.Render(worktree))
+			return nil
+		},
+	}
+
+	lockCmd.Flags().StringVarP(&reason, "reason", "r", "", "Reason for locking the worktree")
+
+	return lockCmd
+}
+
+// completeWorktrees completes the names of the worktrees accepted by filter
+func completeWorktrees(git *git.Git, args []string, filter func(wt git.Worktree) bool) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if err := git.SetWorktreeRoot(); err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	worktrees, err := git.ListWorktrees()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []cobra.Completion
+	for _, wt := range worktrees {
+		if filter(wt) {
+			completions = append(completions, wt.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
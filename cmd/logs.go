@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/logs"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+	"time"
+)
+
+// logEvents are the events logs are kept for, the hook events and those of init_commands and destroy_commands
+var logEvents = append([]string{"init", "destroy"}, _config.HookEvents...)
+
+func Logs(git *_git.Git, selecter *selecter.Select) *cobra.Command {
+	var event string
+	var follow bool
+	var list bool
+
+	logsCmd := &cobra.Command{
+		Use:   "logs [worktree]",
+		Short: "Show the output of the hooks and commands run for a worktree",
+		Long: `Show the latest log of the hooks, init_commands or destroy_commands run for a worktree, with every line of
+their output timestamped and the exit code and duration of each command.
+Without a worktree the logs of the current worktree are shown, or of the one you select outside of a worktree.
+Logs of removed worktrees are found by their name.
+
+Logs are kept in $XDG_STATE_HOME/gwt/logs, or ~/.local/state/gwt/logs, and only the latest ` + fmt.Sprint(logs.MaxLogsPerWorktree) + ` logs of a
+worktree from the last ` + fmt.Sprint(logs.MaxLogAge/(24*time.Hour)) + ` days are kept.`,
+		Args: cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeWorktrees(git, args, func(_git.Worktree) bool { return true })
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+			repoRoot := git.GetWorktreeRoot()
+
+			var name string
+			if len(args) > 0 {
+				name = args[0]
+				if wt, err := git.GetWorktree(name); err == nil {
+					name = wt.Name
+				}
+			} else {
+				wt, err := currentWorktree(git)
+				if err != nil {
+					return err
+				}
+				if wt == nil {
+					worktrees, err := git.ListWorktrees()
+					if err != nil {
+						return err
+					}
+					if len(worktrees) == 0 {
+						return errors.New("no worktrees to show logs of")
+					}
+					worktree, err := selectWorktree(selecter, "Select a worktree to show the logs of:", worktrees)
+					if err != nil {
+						return err
+					}
+					if worktree == "" {
+						return nil
+					}
+					if wt, err = git.GetWorktree(worktree); err != nil {
+						return err
+					}
+				}
+				name = wt.Name
+			}
+
+			entries, err := logs.List(repoRoot, name, event)
+			if err != nil {
+				return err
+			}
+			if len(entries) == 0 {
+				if event != "" {
+					return fmt.Errorf("no %s logs for worktree %s", event, name)
+				}
+				return fmt.Errorf("no logs for worktree %s", name)
+			}
+
+			if list {
+				faintStyle := lipgloss.NewStyle().Faint(true)
+				for _, entry := range entries {
+					fmt.Printf("%s  %-11s  %s\n", entry.Time.Local().Format(time.DateTime), entry.Event, faintStyle.Render(entry.Path))
+				}
+				return nil
+			}
+
+			return printLog(entries[len(entries)-1].Path, follow)
+		},
+	}
+
+	logsCmd.Flags().StringVarP(&event, "event", "e", "", "Only show logs of the event, e.g. post_add or init")
+	logsCmd.Flags().BoolVarP(&follow, "follow", "f", false, "Keep printing lines appended to the log")
+	logsCmd.Flags().BoolVarP(&list, "list", "l", false, "List the logs of the worktree instead of showing the latest")
+	_ = logsCmd.RegisterFlagCompletionFunc("event", cobra.FixedCompletions(logEvents, cobra.ShellCompDirectiveNoFileComp))
+
+	logsCmd.AddCommand(logsCapture())
+
+	return logsCmd
+}
+
+// logsCapture records its input in a log. tmux pipes the output of a session into it while commands sent to the
+// session run.
+func logsCapture() *cobra.Command {
+	return &cobra.Command{
+		Use:    "__capture <log>",
+		Short:  "Record the output of a tmux session in a log",
+		Hidden: true,
+		Args:   cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return logs.Capture(args[0], "tmux", os.Stdin)
+		},
+	}
+}
+
+// printLog prints the log, and with follow keeps printing what is appended to it until gwt is interrupted
+func printLog(path string, follow bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for {
+		if _, err := io.Copy(os.Stdout, file); err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
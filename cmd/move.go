@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/logs"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/jcelaya775/gwt/internal/sesh"
+	"github.com/jcelaya775/gwt/internal/tmux"
+	"github.com/jcelaya775/gwt/internal/zoxide"
+	"github.com/spf13/cobra"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+func Move(git *git.Git, zoxide *zoxide.Zoxide, sesh *sesh.Sesh, tmux *tmux.Tmux, nav *navigator.Navigator) *cobra.Command {
+	var renameRemote bool
+	var force int
+
+	moveCmd := &cobra.Command{
+		Use:     "move <worktree> <new-branch>",
+		Short:   "Rename a worktree and its branch",
+		Aliases: []string{"mv"},
+		Args:    cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeWorktrees(git, args, isUnlocked)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			worktree, newBranch := args[0], args[1]
+
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			wt, err := git.GetWorktree(worktree)
+			if err != nil {
+				return err
+			}
+			cmd.SilenceUsage = true
+			if err := ensureSetupFinished(git, wt.Name); err != nil {
+				return err
+			}
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+			if newBranch, err = checkNewBranchName(git, &config.BranchNaming, newBranch, false); err != nil {
+				return err
+			}
+			oldBranch, err := git.GetWorktreeBranch(worktree)
+			if err != nil {
+				return err
+			}
+			oldUpstream := git.GetUpstream(oldBranch)
+
+			cwd, _ := os.Getwd()
+			if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+				cwd = resolved
+			}
+
+			moved, oldPath, err := git.MoveWorktree(worktree, newBranch, force)
+			if err != nil {
+				return err
+			}
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			fmt.Printf("Worktree %s moved to %s.\n", boldStyle.Render(worktree), boldStyle.Render(moved.Name))
+			if err := _hooks.MoveSetup(git.GetWorktreeRoot(), wt.Name, moved.Name); err != nil {
+				return err
+			}
+			if err := logs.Move(git.GetWorktreeRoot(), wt.Name, moved.Name); err != nil {
+				return err
+			}
+
+			if nav.Enabled() && navigator.IsWithin(cwd, oldPath) {
+				targetDir := moved.Path
+				if relDir, err := filepath.Rel(oldPath, cwd); err == nil {
+					targetDir = filepath.Join(moved.Path, relDir)
+				}
+				if err := nav.ChangeDir(targetDir); err != nil {
+					return err
+				}
+			}
+
+			if renameRemote {
+				if oldUpstream == "" {
+					fmt.Printf("Branch %s has no upstream, skipping the remote rename.\n", boldStyle.Render(oldBranch))
+				} else {
+					if err := git.RenameRemoteBranch(moved.Path, oldUpstream, newBranch); err != nil {
+						return err
+					}
+					fmt.Printf("Remote branch %s renamed to %s.\n", boldStyle.Render(oldUpstream), boldStyle.Render(newBranch))
+				}
+			}
+
+			if err := zoxide.RemovePath(oldPath); err != nil {
+				return err
+			}
+			if err := zoxide.AddPath(moved.Path); err != nil {
+				return err
+			}
+
+			session, err := sesh.FindSession(oldPath)
+			if err != nil && !errors.Is(err, exec.ErrNotFound) {
+				return err
+			}
+			if session != "" {
+				if err := tmux.RenameSession(session, filepath.Base(moved.Path)); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	moveCmd.Flags().BoolVarP(&renameRemote, "remote", "r", false, "Also rename the branch on the remote")
+	moveCmd.Flags().CountVarP(&force, "force", "f", "Pass twice to move a locked worktree")
+
+	return moveCmd
+}
+
+// ensureSetupFinished returns an error while background hooks are still setting the worktree up, since they run in its
+// directory and record their progress under its name
+func ensureSetupFinished(git *git.Git, worktree string) error {
+	setup, err := _hooks.LoadSetup(git.GetWorktreeRoot(), worktree)
+	if err != nil {
+		return err
+	}
+	if setup != nil && setup.Status == _hooks.SetupRunning {
+		return fmt.Errorf("worktree '%s' is still setting up in the background. Move it once gwt list shows it is ready", worktree)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+	"runtime"
+	"time"
+)
+
+func Prune(git *git.Git, selecter *selecter.Select, nav *navigator.Navigator) *cobra.Command {
+	var force int
+	var noSync bool
+	var keepBranches bool
+	var staleDays int
+	var jobs int
+
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove worktrees that are merged, gone or stale",
+		Long: `Find worktrees whose branch is merged (or squash-merged) into the base branch, whose upstream branch
+is gone after fetching, or that have not been used for --stale-days days, and remove the ones you select.
+
+Worktrees with uncommitted changes or unpushed commits are skipped unless --force is passed. Locked worktrees are
+skipped unless --force is passed twice.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			if !noSync {
+				if err := git.FetchPrune(); err != nil {
+					return err
+				}
+			}
+
+			worktrees, err := git.ListWorktrees()
+			if err != nil {
+				return err
+			}
+
+			staleAfter := time.Duration(staleDays) * 24 * time.Hour
+			candidates, err := git.FindPruneCandidates(worktrees, mergeBaseBranch(config), staleAfter, jobs)
+			if err != nil {
+				return err
+			}
+
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			options := make([]string, 0, len(candidates))
+			optionWorktrees := make(map[string]string, len(candidates))
+			for _, candidate := range candidates {
+				if candidate.Status.Locked && force < 2 {
+					fmt.Println(faintStyle.Render(fmt.Sprintf("Skipping %s (%s). Use --force twice (-ff) to include it.",
+						worktreeLabel(candidate.Status.Worktree), candidate.FormatReasons())))
+					continue
+				}
+				if force < 1 && !candidate.Safe() {
+					fmt.Println(faintStyle.Render(fmt.Sprintf("Skipping %s (%s): %s. Use --force to include it.",
+						candidate.Status.Name, candidate.FormatReasons(), unsafeReason(candidate))))
+					continue
+				}
+				option := fmt.Sprintf("%s (%s)", candidate.Status.Name, candidate.FormatReasons())
+				options = append(options, option)
+				optionWorktrees[option] = candidate.Status.Name
+			}
+
+			if len(options) == 0 {
+				fmt.Println("No worktrees to prune.")
+				return nil
+			}
+
+			selected, err := selecter.MultiSelect("Select worktrees to prune:", options)
+			if err != nil {
+				return err
+			}
+
+			worktreesToRemove := make([]string, 0, len(selected))
+			for _, option := range selected {
+				if worktree, ok := optionWorktrees[option]; ok {
+					worktreesToRemove = append(worktreesToRemove, worktree)
+				}
+			}
+
+			cmd.SilenceUsage = true
+			return removeWorktrees(git, config, nav, worktreesToRemove, force, keepBranches)
+		},
+	}
+
+	pruneCmd.Flags().CountVarP(&force, "force", "f", "Include worktrees with uncommitted changes or unpushed commits. Pass twice to include locked worktrees")
+	pruneCmd.Flags().BoolVar(&noSync, "no-sync", false, "Do not fetch remote branches before looking for worktrees to prune")
+	pruneCmd.Flags().BoolVarP(&keepBranches, "keep-branch", "k", false, "Keep the branches of the pruned worktrees")
+	pruneCmd.Flags().IntVar(&staleDays, "stale-days", 0, "Also prune worktrees not used for this many days (0 disables)")
+	pruneCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of worktrees to inspect concurrently")
+
+	return pruneCmd
+}
+
+func unsafeReason(candidate git.PruneCandidate) string {
+	switch {
+	case candidate.Status.Error != "":
+		return candidate.Status.Error
+	case candidate.Status.Dirty():
+		return "has uncommitted changes"
+	default:
+		return "has unpushed commits"
+	}
+}
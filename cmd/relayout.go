@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/logs"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/jcelaya775/gwt/internal/sesh"
+	"github.com/jcelaya775/gwt/internal/tmux"
+	"github.com/jcelaya775/gwt/internal/zoxide"
+	"github.com/spf13/cobra"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// relayoutMove is a worktree that is not at the path its branch's worktree_path gives
+type relayoutMove struct {
+	worktree _git.Worktree
+	newPath  string
+}
+
+func Relayout(git *_git.Git, zoxide *zoxide.Zoxide, sesh *sesh.Sesh, tmux *tmux.Tmux, nav *navigator.Navigator) *cobra.Command {
+	var yes bool
+	var force int
+
+	relayoutCmd := &cobra.Command{
+		Use:   "relayout",
+		Short: "Move existing worktrees to the paths given by worktree_path",
+		Long: `Move existing worktrees to the paths given by defaults.worktree_path and the rules that match their branches.
+
+Change the template first, e.g. with "gwt config set defaults.worktree_path '../{{.Repo}}.worktrees/{{.BranchSlug}}'",
+then run gwt relayout to move the worktrees created with the old template. Worktrees without a branch are left in place.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			worktrees, err := git.ListWorktrees()
+			if err != nil {
+				return err
+			}
+
+			var moves []relayoutMove
+			faintStyle := lipgloss.NewStyle().Faint(true)
+			for _, wt := range worktrees {
+				if wt.Branch == "" {
+					continue
+				}
+				newPath, err := git.WorktreePathFor(wt.BranchName())
+				if err != nil {
+					return err
+				}
+				if newPath != filepath.Clean(wt.Path) {
+					moves = append(moves, relayoutMove{worktree: wt, newPath: newPath})
+				}
+			}
+
+			if len(moves) == 0 {
+				fmt.Println("All worktrees are already in place.")
+				return nil
+			}
+
+			fmt.Println("The following worktrees will be moved:")
+			for _, m := range moves {
+				fmt.Printf("  %s %s\n", m.worktree.Path, faintStyle.Render("-> "+m.newPath))
+			}
+			fmt.Println()
+
+			if !yes {
+				var confirm bool
+				err := huh.NewConfirm().
+					Title(fmt.Sprintf("Move %d worktree(s)?", len(moves))).
+					Affirmative("Yes").
+					Negative("No").
+					Value(&confirm).
+					Run()
+				if err != nil {
+					return err
+				}
+				if !confirm {
+					return nil
+				}
+			}
+
+			cwd, _ := os.Getwd()
+			if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+				cwd = resolved
+			}
+
+			cmd.SilenceUsage = true
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+			failed := 0
+			for _, m := range moves {
+				oldPath := m.worktree.Path
+				if err := ensureSetupFinished(git, m.worktree.Name); err != nil {
+					failed++
+					fmt.Println(orangeStyle.Render(fmt.Sprintf("Skipping %s: %s", worktreeLabel(m.worktree), err)))
+					continue
+				}
+				newPath, err := git.RelayoutWorktree(m.worktree, force)
+				if err != nil {
+					failed++
+					fmt.Println(orangeStyle.Render(fmt.Sprintf("Skipping %s: %s", worktreeLabel(m.worktree), err)))
+					continue
+				}
+				fmt.Printf("Worktree %s moved to %s.\n", boldStyle.Render(m.worktree.BranchName()), newPath)
+				moved, err := git.GetWorktree(m.worktree.BranchName())
+				if err != nil {
+					return err
+				}
+				if err := _hooks.MoveSetup(git.GetWorktreeRoot(), m.worktree.Name, moved.Name); err != nil {
+					return err
+				}
+				if err := logs.Move(git.GetWorktreeRoot(), m.worktree.Name, moved.Name); err != nil {
+					return err
+				}
+
+				if nav.Enabled() && navigator.IsWithin(cwd, oldPath) {
+					targetDir := newPath
+					if relDir, err := filepath.Rel(oldPath, cwd); err == nil {
+						targetDir = filepath.Join(newPath, relDir)
+					}
+					if err := nav.ChangeDir(targetDir); err != nil {
+						return err
+					}
+				}
+				if err := zoxide.RemovePath(oldPath); err != nil {
+					return err
+				}
+				if err := zoxide.AddPath(newPath); err != nil {
+					return err
+				}
+				session, err := sesh.FindSession(oldPath)
+				if err != nil && !errors.Is(err, exec.ErrNotFound) {
+					return err
+				}
+				if session != "" {
+					if err := tmux.RenameSession(session, filepath.Base(newPath)); err != nil {
+						return err
+					}
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d worktree(s) could not be moved", failed)
+			}
+			return nil
+		},
+	}
+
+	relayoutCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Move the worktrees without asking for confirmation")
+	relayoutCmd.Flags().CountVarP(&force, "force", "f", "Pass once to move worktrees with untracked changes and twice to also move locked worktrees")
+
+	return relayoutCmd
+}
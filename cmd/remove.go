@@ -5,16 +5,19 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	_config "github.com/jcelaya775/gwt/internal/config"
 	"github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/navigator"
 	"github.com/jcelaya775/gwt/internal/selecter"
 	"github.com/jcelaya775/gwt/internal/utils"
 	"github.com/spf13/cobra"
+	"os"
 	"path/filepath"
 )
 
-var forceRemove bool
+var forceRemove int
 var keepBranch bool
 
-func Remove(git *git.Git, selecter *selecter.Select) *cobra.Command {
+func Remove(git *git.Git, selecter *selecter.Select, nav *navigator.Navigator) *cobra.Command {
 	removeCmd := &cobra.Command{
 		Use:     "remove [worktree...]",
 		Short:   "Remove a git worktree",
@@ -24,7 +27,7 @@ func Remove(git *git.Git, selecter *selecter.Select) *cobra.Command {
 			if err != nil {
 				return nil, cobra.ShellCompDirectiveError
 			}
-			worktrees, err := git.ListWorktrees()
+			worktrees, err := git.ListWorktreeNames()
 			if err != nil {
 				return nil, cobra.ShellCompDirectiveError
 			}
@@ -47,38 +50,101 @@ func Remove(git *git.Git, selecter *selecter.Select) *cobra.Command {
 
 			if len(worktrees) == 0 {
 				availableWorktrees, err := git.ListWorktrees()
-				worktrees, err = selecter.MultiSelect("Select worktrees to remove:", availableWorktrees)
 				if err != nil {
 					return err
 				}
-			}
-
-			for i, worktree := range worktrees {
-				boldStyle := lipgloss.NewStyle().Bold(true)
-				worktreePath := filepath.Join(git.GetWorktreeRoot(), worktree)
-				if err := utils.RunCommands(config.DestroyCommands, worktreePath, false, worktree); err != nil {
-					return err
-				}
-				if len(config.DestroyCommands) > 0 {
-					fmt.Println()
-				}
-
-				if err := git.RemoveWorktree(worktree, forceRemove, keepBranch); err != nil {
+				worktrees, err = selectWorktrees(selecter, "Select worktrees to remove:", availableWorktrees)
+				if err != nil {
 					return err
 				}
-				fmt.Printf("Worktree %s removed successfully.\n", boldStyle.Render(worktree))
-
-				if i < len(worktrees)-1 {
-					fmt.Println()
-				}
 			}
 
-			return nil
+			cmd.SilenceUsage = true
+			return removeWorktrees(git, config, nav, worktrees, forceRemove, keepBranch)
 		},
 	}
 
-	removeCmd.Flags().BoolVarP(&forceRemove, "force", "f", false, "Force removal of the worktree even if there are uncommitted changes")
+	removeCmd.Flags().CountVarP(&forceRemove, "force", "f", "Force removal of the worktree even if there are uncommitted changes. Pass twice to also remove locked worktrees")
 	removeCmd.Flags().BoolVarP(&keepBranch, "keep-branch", "k", false, "Also delete the branch associated with the worktree")
 
 	return removeCmd
 }
+
+// removeWorktrees runs the destroy commands for each worktree, removes it, and moves the shell out of it if needed.
+// Locked worktrees are skipped unless force is at least two.
+func removeWorktrees(git *git.Git, config *_config.Config, nav *navigator.Navigator, worktrees []string, force int, keepBranch bool) error {
+	if len(worktrees) > 0 {
+		if err := ensureTrusted(git, os.Stdout); err != nil {
+			return err
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+		cwd = resolved
+	}
+
+	boldStyle := lipgloss.NewStyle().Bold(true)
+	for i, worktree := range worktrees {
+		wt, err := git.GetWorktree(worktree)
+		if err != nil {
+			return err
+		}
+		if wt.Locked && force < 2 {
+			orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+			fmt.Println(orangeStyle.Render(fmt.Sprintf("Skipping %s. Use --force twice (-ff) to remove it anyway.",
+				worktreeLabel(*wt))))
+			if i < len(worktrees)-1 {
+				fmt.Println()
+			}
+			continue
+		}
+		branchConfig := config.ForBranch(wt.BranchName())
+		destroyCommands := branchConfig.DestroyCommands
+		baseBranch, err := git.ResolveBaseBranch(config, branchConfig.BaseBranch)
+		if err != nil {
+			baseBranch = branchConfig.BaseBranch
+		}
+		hookCtx := commandContext(git, _config.PreRemove, wt.Path, wt.Name, wt.BranchName(), baseBranch)
+		if err := _hooks.Run(branchConfig.Hooks.PreRemove, hookCtx, os.Stdout, branchConfig.Hooks.Jobs); err != nil {
+			return err
+		}
+		if len(branchConfig.Hooks.PreRemove) > 0 {
+			fmt.Println()
+		}
+		commandCtx := commandContext(git, "destroy", wt.Path, wt.Name, wt.BranchName(), baseBranch)
+		if err := utils.RunCommands(destroyCommands, commandCtx, false); err != nil {
+			return err
+		}
+		if len(destroyCommands) > 0 {
+			fmt.Println()
+		}
+
+		if err := git.RemoveWorktree(worktree, force, keepBranch); err != nil {
+			return err
+		}
+		if !utils.DryRun() {
+			fmt.Printf("Worktree %s removed successfully.\n", boldStyle.Render(worktree))
+		}
+		if err := _hooks.RemoveSetup(git.GetWorktreeRoot(), wt.Name); err != nil {
+			return err
+		}
+
+		if nav.Enabled() && navigator.IsWithin(cwd, wt.Path) {
+			if err := nav.ChangeDir(git.GetWorktreeRoot()); err != nil {
+				return err
+			}
+		}
+
+		hookCtx.Event = _config.PostRemove
+		if err := _hooks.Run(branchConfig.Hooks.PostRemove, hookCtx, os.Stdout, branchConfig.Hooks.Jobs); err != nil {
+			return err
+		}
+
+		if i < len(worktrees)-1 {
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
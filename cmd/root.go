@@ -4,21 +4,52 @@ Copyright © 2025 Jorge Celaya jcelaya775@gmail.com
 package cmd
 
 import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
 	_connector "github.com/jcelaya775/gwt/internal/connector"
 	_git "github.com/jcelaya775/gwt/internal/git"
 	_home "github.com/jcelaya775/gwt/internal/home"
+	_navigator "github.com/jcelaya775/gwt/internal/navigator"
 	_selecter "github.com/jcelaya775/gwt/internal/selecter"
+	_sesh "github.com/jcelaya775/gwt/internal/sesh"
 	_shell "github.com/jcelaya775/gwt/internal/shell"
+	_tmux "github.com/jcelaya775/gwt/internal/tmux"
+	"github.com/jcelaya775/gwt/internal/utils"
 	_zoxide "github.com/jcelaya775/gwt/internal/zoxide"
 	"log"
 	"os"
+	"slices"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+var configPath string
+var dryRun bool
+var trustOnce bool
+
+// Styles shared by the commands
+var boldStyle = lipgloss.NewStyle().Bold(true)
+var faintStyle = lipgloss.NewStyle().Faint(true)
+var greenStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+var orangeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+var redStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+
+// dryRunCommands are the commands that print what they would do with --dry-run
+var dryRunCommands = []string{"gwt add", "gwt clone", "gwt hooks run", "gwt prune", "gwt remove"}
+
 var rootCmd = &cobra.Command{
 	Use:   "gwt",
 	Short: "A git worktree wrapper that makes life easier\n\n",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		_config.SetExplicitPath(configPath)
+		if dryRun && !slices.Contains(dryRunCommands, cmd.CommandPath()) {
+			return fmt.Errorf("%s does not support --dry-run. Commands that do: %s", cmd.CommandPath(), strings.Join(dryRunCommands, ", "))
+		}
+		utils.SetDryRun(dryRun)
+		return nil
+	},
 	/// TODO: Show TUI when no subcommand is provided
 }
 
@@ -34,12 +65,32 @@ func Execute() {
 	shell := _shell.NewShell(home)
 	zoxide := _zoxide.New(shell)
 	connector := _connector.New(shell)
+	navigator := _navigator.New()
+	sesh := _sesh.New(shell)
+	tmux := _tmux.NewTmux()
+
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file that overrides the global, repository and local config")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the git commands, file copies and hooks that would run without running them")
+	rootCmd.PersistentFlags().BoolVar(&trustOnce, "trust-once", false, "Run the hooks and commands in .gwt.yml without trusting them for later, e.g. in CI")
 
-	rootCmd.AddCommand(Add(git, selecter, zoxide, connector))
+	rootCmd.AddCommand(Add(git, selecter, zoxide, connector, tmux, navigator))
 	rootCmd.AddCommand(Clone(git))
 	rootCmd.AddCommand(List(git))
-	rootCmd.AddCommand(Remove(git, selecter))
+	rootCmd.AddCommand(Remove(git, selecter, navigator))
 	rootCmd.AddCommand(Init(git))
+	rootCmd.AddCommand(Switch(git, selecter, navigator))
+	rootCmd.AddCommand(ShellInit())
+	rootCmd.AddCommand(Status(git))
+	rootCmd.AddCommand(Prune(git, selecter, navigator))
+	rootCmd.AddCommand(Convert(git, navigator))
+	rootCmd.AddCommand(Lock(git, selecter))
+	rootCmd.AddCommand(Unlock(git, selecter))
+	rootCmd.AddCommand(Move(git, zoxide, sesh, tmux, navigator))
+	rootCmd.AddCommand(Config(git))
+	rootCmd.AddCommand(Relayout(git, zoxide, sesh, tmux, navigator))
+	rootCmd.AddCommand(Hooks(git, selecter))
+	rootCmd.AddCommand(Logs(git, selecter))
+	rootCmd.AddCommand(Trust(git))
 
 	err = rootCmd.Execute()
 	if err != nil {
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/selecter"
+)
+
+// worktreeLabel renders a worktree for selection, including its lock state
+func worktreeLabel(wt git.Worktree) string {
+	switch {
+	case wt.Locked && wt.LockedReason != "":
+		return fmt.Sprintf("%s (locked: %s)", wt.Name, wt.LockedReason)
+	case wt.Locked:
+		return fmt.Sprintf("%s (locked)", wt.Name)
+	default:
+		return wt.Name
+	}
+}
+
+// selectWorktree prompts for one of the worktrees and returns its name, or an empty string if none was selected
+func selectWorktree(selecter *selecter.Select, header string, worktrees []git.Worktree) (string, error) {
+	labels, names := worktreeLabels(worktrees)
+	selected, err := selecter.Select(header, labels)
+	if err != nil {
+		return "", err
+	}
+	return names[selected], nil
+}
+
+// selectWorktrees prompts for any number of the worktrees and returns their names
+func selectWorktrees(selecter *selecter.Select, header string, worktrees []git.Worktree) ([]string, error) {
+	labels, names := worktreeLabels(worktrees)
+	selected, err := selecter.MultiSelect(header, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedNames := make([]string, 0, len(selected))
+	for _, label := range selected {
+		if name, ok := names[label]; ok {
+			selectedNames = append(selectedNames, name)
+		}
+	}
+	return selectedNames, nil
+}
+
+func isLocked(wt git.Worktree) bool {
+	return wt.Locked
+}
+
+func isUnlocked(wt git.Worktree) bool {
+	return !wt.Locked
+}
+
+func worktreeLabels(worktrees []git.Worktree) ([]string, map[string]string) {
+	labels := make([]string, 0, len(worktrees))
+	names := make(map[string]string, len(worktrees))
+	for _, wt := range worktrees {
+		label := worktreeLabel(wt)
+		labels = append(labels, label)
+		names[label] = wt.Name
+	}
+	return labels, names
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/spf13/cobra"
+)
+
+func ShellInit() *cobra.Command {
+	return &cobra.Command{
+		Use:   "shell-init <bash|zsh|fish>",
+		Short: "Print the shell integration that lets gwt change your directory",
+		Long: `Print a wrapper function that lets gwt switch, add and remove change the directory of your shell.
+
+Add one of the following to your shell config:
+  bash: eval "$(gwt shell-init bash)"
+  zsh:  eval "$(gwt shell-init zsh)"
+  fish: gwt shell-init fish | source`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: navigator.SupportedShells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			script, err := navigator.Script(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Print(script)
+			return nil
+		},
+	}
+}
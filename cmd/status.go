@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/spf13/cobra"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+func Status(git *git.Git) *cobra.Command {
+	var jsonOutput bool
+	var jobs int
+
+	statusCmd := &cobra.Command{
+		Use:     "status",
+		Short:   "Show the status of all worktrees",
+		Aliases: []string{"st"},
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+
+			worktrees, err := git.ListWorktrees()
+			if err != nil {
+				return err
+			}
+
+			statuses := git.GetWorktreeStatuses(worktrees, mergeBaseBranch(config), jobs)
+
+			if jsonOutput {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(statuses)
+			}
+
+			printStatusTable(statuses)
+			return nil
+		},
+	}
+
+	statusCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output the status as JSON")
+	statusCmd.Flags().IntVarP(&jobs, "jobs", "j", runtime.NumCPU(), "Number of worktrees to query concurrently")
+
+	return statusCmd
+}
+
+// mergeBaseBranch returns the base_branch of a branch, which its worktree is compared with. Branches based on
+// @latest-tag are merged into defaults.base_branch.
+func mergeBaseBranch(config *_config.Config) func(branch string) string {
+	return func(branch string) string {
+		baseBranch := config.ForBranch(branch).BaseBranch
+		if baseBranch == _config.LatestTagBaseBranch {
+			return config.Defaults.BaseBranch
+		}
+		return baseBranch
+	}
+}
+
+func printStatusTable(statuses []git.WorktreeStatus) {
+	rows := make([][]string, 0, len(statuses))
+	for _, s := range statuses {
+		branch := s.BranchName()
+		if s.Detached {
+			branch = faintStyle.Render(fmt.Sprintf("(detached %s)", shortSha(s.Head)))
+		}
+
+		var changes string
+		switch {
+		case s.Error != "":
+			changes = redStyle.Render(s.Error)
+		case s.Dirty():
+			var parts []string
+			if s.Conflicts > 0 {
+				parts = append(parts, redStyle.Render(fmt.Sprintf("!%d", s.Conflicts)))
+			}
+			if s.Staged > 0 {
+				parts = append(parts, greenStyle.Render(fmt.Sprintf("+%d", s.Staged)))
+			}
+			if s.Unstaged > 0 {
+				parts = append(parts, orangeStyle.Render(fmt.Sprintf("~%d", s.Unstaged)))
+			}
+			if s.Untracked > 0 {
+				parts = append(parts, faintStyle.Render(fmt.Sprintf("?%d", s.Untracked)))
+			}
+			changes = strings.Join(parts, " ")
+		default:
+			changes = greenStyle.Render("clean")
+		}
+
+		upstream := faintStyle.Render("-")
+		if s.Upstream != "" {
+			upstream = formatAheadBehind(s.Ahead, s.Behind)
+		}
+
+		base := faintStyle.Render("-")
+		if s.BranchName() != s.BaseBranch {
+			base = formatAheadBehind(s.BaseAhead, s.BaseBehind)
+		}
+
+		lastCommit := ""
+		if !s.LastCommitTime.IsZero() {
+			lastCommit = fmt.Sprintf("%s %s", truncate(s.LastCommitSubject, 40), faintStyle.Render(formatAge(s.LastCommitTime)))
+		}
+
+		stashes := ""
+		if s.Stashes > 0 {
+			stashes = fmt.Sprint(s.Stashes)
+		}
+
+		lock := ""
+		if s.Locked {
+			lock = orangeStyle.Render("locked")
+			if s.LockedReason != "" {
+				lock += faintStyle.Render(": " + s.LockedReason)
+			}
+		}
+
+		rows = append(rows, []string{s.Name, branch, changes, upstream, base, lastCommit, stashes, lock})
+	}
+
+	t := table.New().
+		Border(lipgloss.HiddenBorder()).
+		BorderTop(false).
+		BorderBottom(false).
+		BorderHeader(false).
+		Headers("WORKTREE", "BRANCH", "CHANGES", "UPSTREAM", "BASE", "LAST COMMIT", "STASH", "LOCK").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return boldStyle
+			}
+			return lipgloss.NewStyle()
+		})
+	fmt.Println(t)
+}
+
+func formatAheadBehind(ahead, behind int) string {
+	if ahead == 0 && behind == 0 {
+		return "="
+	}
+	var parts []string
+	if ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", ahead))
+	}
+	if behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", behind))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatAge renders the time since t in its largest unit, e.g. "3d ago"
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo ago", int(d.Hours()/24/30))
+	default:
+		return fmt.Sprintf("%dy ago", int(d.Hours()/24/365))
+	}
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
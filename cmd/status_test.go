@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"testing"
+)
+
+func TestMergeBaseBranch(t *testing.T) {
+	config := &_config.Config{
+		Defaults: _config.Defaults{BaseBranch: "main"},
+		Rules: []_config.Rule{
+			{Match: "feature/*", BaseBranch: "develop"},
+			{Match: "release/*", BaseBranch: _config.LatestTagBaseBranch},
+			{Match: "feature/legacy-*", BaseBranch: "legacy"},
+		},
+	}
+
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{branch: "fix/login", want: "main"},
+		{branch: "feature/login", want: "develop"},
+		{branch: "feature/legacy-login", want: "legacy"},
+		{branch: "release/1.2", want: "main"},
+		{branch: "", want: "main"},
+	}
+
+	baseBranch := mergeBaseBranch(config)
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := baseBranch(tt.branch); got != tt.want {
+				t.Errorf("mergeBaseBranch()(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
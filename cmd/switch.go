@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/git"
+	_hooks "github.com/jcelaya775/gwt/internal/hooks"
+	"github.com/jcelaya775/gwt/internal/navigator"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+func Switch(git *git.Git, selecter *selecter.Select, nav *navigator.Navigator) *cobra.Command {
+	return &cobra.Command{
+		Use:     "switch [worktree]",
+		Short:   "Change into a worktree",
+		Aliases: []string{"sw"},
+		Args:    cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			if len(args) > 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			err := git.SetWorktreeRoot()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			worktrees, err := git.ListWorktreeNames()
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return worktrees, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			var worktree string
+			if len(args) == 0 {
+				worktrees, err := git.ListWorktrees()
+				if err != nil {
+					return err
+				}
+				if len(worktrees) == 0 {
+					return errors.New("no worktrees available to switch to")
+				}
+				worktree, err = selectWorktree(selecter, "Select a worktree to switch to:", worktrees)
+				if err != nil {
+					return err
+				}
+				if worktree == "" {
+					return nil
+				}
+			} else {
+				worktree = args[0]
+			}
+
+			wt, err := git.GetWorktree(worktree)
+			if err != nil {
+				return fmt.Errorf("%w: %s", err, worktree)
+			}
+
+			config, err := _config.LoadConfig(git.GetWorktreeRoot())
+			if err != nil {
+				return err
+			}
+			branchConfig := config.ForBranch(wt.BranchName())
+			baseBranch, err := git.ResolveBaseBranch(config, branchConfig.BaseBranch)
+			if err != nil {
+				baseBranch = branchConfig.BaseBranch
+			}
+			hookCtx := commandContext(git, _config.PostSwitch, wt.Path, wt.Name, wt.BranchName(), baseBranch)
+
+			if len(branchConfig.Hooks.PostSwitch) > 0 {
+				// Without the shell integration stdout is kept for the path
+				cmd.SilenceUsage = true
+				if err := ensureTrusted(git, os.Stderr); err != nil {
+					return err
+				}
+			}
+
+			targetDir := wt.Path
+			if currentWorktreePath, err := git.GetCurrentWorktreePath(); err == nil {
+				targetDir = navigator.PreserveSubdir(currentWorktreePath, wt.Path)
+			}
+
+			if !nav.Enabled() {
+				// Print the path so `cd "$(gwt switch ...)"` still works without the shell integration, and keep the
+				// output of the hooks out of it
+				fmt.Fprintln(os.Stderr, navigator.ErrShellIntegrationDisabled)
+				if err := _hooks.Run(branchConfig.Hooks.PostSwitch, hookCtx, os.Stderr, branchConfig.Hooks.Jobs); err != nil {
+					return err
+				}
+				fmt.Println(targetDir)
+				return nil
+			}
+			if err := nav.ChangeDir(targetDir); err != nil {
+				return err
+			}
+			return _hooks.Run(branchConfig.Hooks.PostSwitch, hookCtx, os.Stdout, branchConfig.Hooks.Jobs)
+		},
+	}
+}
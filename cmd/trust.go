@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	_git "github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/trust"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"github.com/spf13/cobra"
+	"io"
+	"os"
+)
+
+func Trust(git *_git.Git) *cobra.Command {
+	var yes bool
+	var revoke bool
+
+	trustCmd := &cobra.Command{
+		Use:   "trust",
+		Short: "Trust the hooks and commands in the repository's .gwt.yml",
+		Long: `Trust the hooks and commands in the repository's .gwt.yml after showing what changed since you last trusted them.
+
+.gwt.yml is part of the repository, so pulling a branch can change the commands gwt runs. gwt keeps a hash of the
+hooks, init_commands and destroy_commands you trusted, and refuses to run them once they change until you trust them
+again. In a terminal, commands that would run them offer to do so right away. The global config, .gwt.local.yml and
+the file passed with --config are yours and always trusted, unless .gwt.local.yml is committed to the repository, in
+which case its hooks and commands need trust like the ones in .gwt.yml.
+
+Pass --trust-once to any command to run the hooks without trusting them for later, e.g. in CI.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+			repoRoot := git.GetWorktreeRoot()
+
+			if revoke {
+				if err := trust.Revoke(repoRoot); err != nil {
+					return err
+				}
+				fmt.Println("gwt will ask before running the hooks and commands in .gwt.yml again.")
+				return nil
+			}
+
+			status, err := trustStatus(git)
+			if err != nil {
+				return err
+			}
+			if len(status.Commands) == 0 {
+				fmt.Println("There are no hooks or commands in .gwt.yml to trust.")
+				return nil
+			}
+			if status.Trusted() {
+				fmt.Println("The hooks and commands in .gwt.yml are already trusted.")
+				return nil
+			}
+
+			printTrustDiff(os.Stdout, status)
+			trusted := yes
+			if !trusted {
+				if !term.IsTerminal(os.Stdin.Fd()) {
+					return errors.New("pass --yes to trust them without a prompt")
+				}
+				err := huh.NewConfirm().
+					Title("Trust these hooks and commands?").
+					Affirmative("Yes").
+					Negative("No").
+					Value(&trusted).
+					Run()
+				if err != nil {
+					return err
+				}
+			}
+			if !trusted {
+				return nil
+			}
+			if err := trust.Trust(repoRoot, status.Commands); err != nil {
+				return err
+			}
+			fmt.Println(greenStyle.Render("Trusted the hooks and commands in .gwt.yml."))
+			return nil
+		},
+	}
+
+	trustCmd.Flags().BoolVarP(&yes, "yes", "y", false, "Trust the hooks and commands without a prompt")
+	trustCmd.Flags().BoolVar(&revoke, "revoke", false, "Forget that the hooks and commands were trusted")
+	trustCmd.MarkFlagsMutuallyExclusive("yes", "revoke")
+
+	return trustCmd
+}
+
+func trustStatus(git *_git.Git) (*trust.Status, error) {
+	commands, err := _config.RepoCommands(git.GetWorktreeRoot(), git.IsTracked(_config.LocalConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+	return trust.Check(git.GetWorktreeRoot(), commands)
+}
+
+// ensureTrusted returns an error when the hooks and commands in the repository's .gwt.yml are not trusted. In a
+// terminal it shows what changed on out and offers to trust them first. With --trust-once they run without being
+// trusted for later, and a dry run only warns, since it runs nothing.
+func ensureTrusted(git *_git.Git, out io.Writer) error {
+	status, err := trustStatus(git)
+	if err != nil {
+		return err
+	}
+	if status.Trusted() || trustOnce {
+		return nil
+	}
+
+	untrusted := errors.New("the hooks and commands in .gwt.yml are not trusted yet. Review them with gwt trust, or pass --trust-once to run them this time")
+	if status.Approval != nil {
+		untrusted = errors.New("the hooks and commands in .gwt.yml changed since you trusted them. Review them with gwt trust, or pass --trust-once to run them this time")
+	}
+	if utils.DryRun() {
+		fmt.Fprintln(out, orangeStyle.Render(fmt.Sprintf("Warning: %s.", untrusted)))
+		return nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return untrusted
+	}
+
+	printTrustDiff(out, status)
+	trusted := false
+	err = huh.NewConfirm().
+		Title("Trust these hooks and commands and go on?").
+		Affirmative("Yes").
+		Negative("No").
+		Value(&trusted).
+		Run()
+	if err != nil {
+		return err
+	}
+	if !trusted {
+		return untrusted
+	}
+	return trust.Trust(git.GetWorktreeRoot(), status.Commands)
+}
+
+// printTrustDiff shows how the hooks and commands in .gwt.yml differ from the ones trusted last
+func printTrustDiff(out io.Writer, status *trust.Status) {
+	var trusted []string
+	if status.Approval != nil {
+		fmt.Fprintln(out, boldStyle.Render(fmt.Sprintf("The hooks and commands in .gwt.yml changed since you trusted them on %s:",
+			status.Approval.Trusted.Format("2006-01-02 15:04"))))
+		trusted = status.Approval.Commands
+	} else {
+		fmt.Fprintln(out, boldStyle.Render("The hooks and commands in .gwt.yml are not trusted yet:"))
+	}
+	for _, line := range trust.Diff(trusted, status.Commands) {
+		switch line.Change {
+		case trust.Added:
+			fmt.Fprintln(out, greenStyle.Render("+ "+line.Text))
+		case trust.Removed:
+			fmt.Fprintln(out, redStyle.Render("- "+line.Text))
+		default:
+			fmt.Fprintln(out, faintStyle.Render("  "+line.Text))
+		}
+	}
+	fmt.Fprintln(out)
+}
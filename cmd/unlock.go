@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jcelaya775/gwt/internal/git"
+	"github.com/jcelaya775/gwt/internal/selecter"
+	"github.com/spf13/cobra"
+)
+
+func Unlock(git *git.Git, selecter *selecter.Select) *cobra.Command {
+	return &cobra.Command{
+		Use:   "unlock [worktree]",
+		Short: "Unlock a locked worktree",
+		Args:  cobra.MaximumNArgs(1),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+			return completeWorktrees(git, args, isLocked)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := git.SetWorktreeRoot(); err != nil {
+				return err
+			}
+
+			var worktree string
+			if len(args) == 0 {
+				worktrees, err := git.ListWorktrees()
+				if err != nil {
+					return err
+				}
+				locked := worktrees[:0]
+				for _, wt := range worktrees {
+					if wt.Locked {
+						locked = append(locked, wt)
+					}
+				}
+				if len(locked) == 0 {
+					return errors.New("no locked worktrees to unlock")
+				}
+				worktree, err = selectWorktree(selecter, "Select a worktree to unlock:", locked)
+				if err != nil {
+					return err
+				}
+				if worktree == "" {
+					return nil
+				}
+			} else {
+				worktree = args[0]
+			}
+
+			if err := git.UnlockWorktree(worktree); err != nil {
+				return err
+			}
+			boldStyle := lipgloss.NewStyle().Bold(true)
+			fmt.Printf("Worktree %s unlocked.\n", boldStyle.Render(worktree))
+			return nil
+		},
+	}
+}
@@ -2,58 +2,115 @@ package config
 
 import (
 	"fmt"
-	"gopkg.in/yaml.v3"
 	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 type Config struct {
-	Version         string   `yaml:"version"`
-	InitCommands    []string `yaml:"init_commands,omitempty"` // Commands to run when initializing a repository
-	Defaults        Defaults `yaml:"defaults,omitempty"`
-	DestroyCommands []string `yaml:"destroy_commands,omitempty"` // Commands to run when destroying a worktree
+	Version         string       `yaml:"version" desc:"Version of the configuration schema"`
+	InitCommands    []string     `yaml:"init_commands,omitempty" desc:"Commands to run after creating a worktree"`
+	Defaults        Defaults     `yaml:"defaults,omitempty" desc:"Default settings for worktrees"`
+	DestroyCommands []string     `yaml:"destroy_commands,omitempty" desc:"Commands to run before removing a worktree"`
+	Rules           []Rule       `yaml:"rules,omitempty" desc:"Settings for branches matching a glob or regular expression"`
+	Copy            []string     `yaml:"copy,omitempty" desc:"Globs of untracked files to copy from the source worktree into new worktrees, e.g. .env or .idea/runConfigurations/**"`
+	Symlink         []string     `yaml:"symlink,omitempty" desc:"Globs of untracked files to symlink from the source worktree into new worktrees, e.g. node_modules"`
+	Hooks           Hooks        `yaml:"hooks,omitempty" desc:"Commands to run on worktree lifecycle events"`
+	BranchNaming    BranchNaming `yaml:"branch_naming,omitempty" desc:"Convention the names of branches created by gwt add must follow"`
 }
 
 type Defaults struct {
-	BaseBranch string `yaml:"base_branch,omitempty"` // Default base branch for new worktrees
+	BaseBranch   string   `yaml:"base_branch,omitempty" desc:"Default base branch for new worktrees"`
+	Layout       string   `yaml:"layout,omitempty" desc:"Repository layout used by gwt clone" enum:"bare,dummy"`
+	WorktreePath string   `yaml:"worktree_path,omitempty" desc:"Template of the path of new worktrees relative to the repository root, using .Repo, .Root, .Branch and .BranchSlug and the replace, lower, upper and slug functions"`
+	Open         []string `yaml:"open,omitempty" desc:"Tools to open new worktrees with" enum:"sesh,webstorm,idea,pycharm,clion,rider,goland,datagrip"`
+	CopyFrom     string   `yaml:"copy_from,omitempty" desc:"Branch or worktree to copy and symlink files from. Defaults to the base branch"`
+	CopyMode     string   `yaml:"copy_mode,omitempty" desc:"How files are copied: auto uses copy-on-write clones when the file system supports them and falls back to a full copy. Hardlinked files share their content with the source" enum:"auto,reflink,hardlink,copy"`
+	CopyMaxSize  string   `yaml:"copy_max_size,omitempty" desc:"Largest total size copied without copy-on-write before further files are skipped, e.g. 500MB"`
 }
 
 const (
 	ConfigFileName    = ".gwt.yml"
-	CurrentVersion    = "1.0"
+	CurrentVersion    = "1.1"
 	DefaultBaseBranch = "main"
+	// DefaultWorktreePath places each worktree in a directory named after its branch in the repository root
+	DefaultWorktreePath = "{{.Branch}}"
+	DefaultCopyMode     = "auto"
+	DefaultCopyMaxSize  = "1GB"
 )
 
-// LoadConfig loads configuration from .gwt.yml in the repository root
+// LoadConfig loads the merged configuration of the global config, .gwt.yml and .gwt.local.yml in the repository root,
+// and the file passed with --config, in increasing order of precedence
 func LoadConfig(repoRoot string) (*Config, error) {
-	configPath := filepath.Join(repoRoot, ConfigFileName)
+	config, _, err := LoadConfigWithOrigins(repoRoot)
+	return config, err
+}
+
+var printedWarnings = make(map[Issue]bool)
 
-	// If config file doesn't exist, return default config
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return &Config{
-			Version: CurrentVersion,
-			Defaults: Defaults{
-				BaseBranch: DefaultBaseBranch,
-			},
-		}, nil
+// LoadConfigWithOrigins loads the merged configuration like LoadConfig and reports where each value was defined.
+// Warnings, such as deprecated keys, are printed to stderr.
+func LoadConfigWithOrigins(repoRoot string) (*Config, Origins, error) {
+	config, origins, issues, err := loadConfig(repoRoot)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	configBytes, err := os.ReadFile(configPath)
+	hasErrors := false
+	for _, issue := range issues {
+		if issue.Warning {
+			// The configuration can be loaded more than once per command, but each warning is only worth one line
+			if !printedWarnings[issue] {
+				printedWarnings[issue] = true
+				fmt.Fprintf(os.Stderr, "warning: %s\n", issue)
+			}
+		} else {
+			hasErrors = true
+		}
+	}
+	if hasErrors {
+		return nil, nil, &ValidationError{Issues: issues}
+	}
+
+	return config, origins, nil
+}
+
+// ValidateConfig checks every configuration layer and the merged result, returning the errors and warnings found
+func ValidateConfig(repoRoot string) ([]Issue, error) {
+	_, _, issues, err := loadConfig(repoRoot)
+	return issues, err
+}
+
+func loadConfig(repoRoot string) (*Config, Origins, []Issue, error) {
+	layers, err := loadLayers(repoRoot)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, nil, nil, err
+	}
+
+	var issues []Issue
+	for i := range layers {
+		issues = append(issues, prepareLayer(&layers[i])...)
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			return nil, nil, issues, nil
+		}
 	}
 
+	merged, origins := mergeLayers(layers)
+
 	var config Config
-	if err := yaml.Unmarshal(configBytes, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if err := merged.Decode(&config); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
 	// Validate configuration
 	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration: %w", err)
+		return nil, nil, nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	return &config, nil
+	return &config, origins, issues, nil
 }
 
 func (c *Config) Validate() error {
@@ -65,5 +122,60 @@ func (c *Config) Validate() error {
 		c.Defaults.BaseBranch = DefaultBaseBranch
 	}
 
+	if c.Defaults.CopyMode == "" {
+		c.Defaults.CopyMode = DefaultCopyMode
+	}
+
+	if c.Defaults.CopyMaxSize == "" {
+		c.Defaults.CopyMaxSize = DefaultCopyMaxSize
+	}
+	if _, err := ParseSize(c.Defaults.CopyMaxSize); err != nil {
+		return fmt.Errorf("defaults.copy_max_size: %w", err)
+	}
+
+	if c.Defaults.WorktreePath != "" {
+		if _, err := parseWorktreePath(c.Defaults.WorktreePath); err != nil {
+			return fmt.Errorf("defaults: %w", err)
+		}
+	}
+
+	if err := c.Hooks.validate(); err != nil {
+		return fmt.Errorf("hooks.%w", err)
+	}
+
+	if err := c.BranchNaming.validate(); err != nil {
+		return fmt.Errorf("branch_naming: %w", err)
+	}
+
+	for i := range c.Rules {
+		if err := c.Rules[i].validate(); err != nil {
+			return fmt.Errorf("rules[%d]: %w", i, err)
+		}
+	}
+
 	return nil
 }
+
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1 << 10,
+	"MB": 1 << 20,
+	"GB": 1 << 30,
+	"TB": 1 << 40,
+}
+
+// ParseSize parses a size such as 500MB or 2GB into bytes. Units are powers of 1024.
+func ParseSize(size string) (int64, error) {
+	size = strings.TrimSpace(size)
+	number := strings.TrimRightFunc(size, unicode.IsLetter)
+	unit := strings.ToUpper(strings.TrimSpace(size[len(number):]))
+	unit = strings.Replace(unit, "IB", "B", 1)
+
+	multiplier, ok := sizeUnits[unit]
+	value, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if !ok || err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s'. Expected a size like 500MB or 2GB", size)
+	}
+	return int64(value * float64(multiplier)), nil
+}
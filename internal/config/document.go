@@ -0,0 +1,414 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Document is a single configuration file edited as a YAML node tree, so comments, key order and formatting
+// survive a round trip
+type Document struct {
+	Path     string
+	document *yaml.Node
+}
+
+// keySegment is one part of a dotted key: a mapping key, or a list index when isIndex is set
+type keySegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// OpenDocument reads the configuration file at path. A missing file yields a document with only the current version.
+func OpenDocument(path string) (*Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return parseDocument(path, content)
+}
+
+func parseDocument(path string, content []byte) (*Document, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		// New files start at the current version so later migrations apply to them
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"},
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: CurrentVersion, Style: yaml.DoubleQuotedStyle},
+		}}
+		document = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file %s: expected a mapping at the top level", path)
+	}
+	return &Document{Path: path, document: &document}, nil
+}
+
+// Get returns the value at the dotted key, e.g. defaults.base_branch or init_commands[2]
+func (d *Document) Get(key string) (*yaml.Node, error) {
+	segments, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	node := d.document.Content[0]
+	for _, segment := range segments {
+		node = child(node, segment)
+		if node == nil {
+			return nil, fmt.Errorf("key '%s' is not set in %s", key, d.Path)
+		}
+	}
+	return node, nil
+}
+
+// Set sets the dotted key to value, creating missing parents. An index equal to the length of a list appends to it.
+// The value is parsed as YAML unless the schema expects a string at key.
+func (d *Document) Set(key string, value string) error {
+	segments, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	valueNode, err := parseValue(key, segments, value)
+	if err != nil {
+		return err
+	}
+
+	node := d.document.Content[0]
+	for i, segment := range segments {
+		last := i == len(segments)-1
+		if segment.isIndex {
+			if node.Kind != yaml.SequenceNode {
+				return fmt.Errorf("'%s' is not a list", joinKey(segments[:i]))
+			}
+			switch {
+			case segment.index < len(node.Content):
+				if last {
+					valueNode.HeadComment = node.Content[segment.index].HeadComment
+					valueNode.LineComment = node.Content[segment.index].LineComment
+					node.Content[segment.index] = valueNode
+					return nil
+				}
+			case segment.index == len(node.Content):
+				if last {
+					node.Content = append(node.Content, valueNode)
+					return nil
+				}
+				node.Content = append(node.Content, newContainer(segments[i+1]))
+			default:
+				return fmt.Errorf("index %d is out of range for '%s' with %d items", segment.index, joinKey(segments[:i]), len(node.Content))
+			}
+			node = node.Content[segment.index]
+			continue
+		}
+
+		if node.Kind != yaml.MappingNode {
+			if isNull(node) {
+				node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+			} else {
+				return fmt.Errorf("'%s' is not a mapping", joinKey(segments[:i]))
+			}
+		}
+		index := mappingIndex(node, segment.key)
+		if index < 0 {
+			keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment.key}
+			var next *yaml.Node
+			if last {
+				next = valueNode
+			} else {
+				next = newContainer(segments[i+1])
+			}
+			node.Content = append(node.Content, keyNode, next)
+			if last {
+				return nil
+			}
+			node = next
+			continue
+		}
+		if last {
+			valueNode.LineComment = node.Content[index+1].LineComment
+			node.Content[index+1] = valueNode
+			return nil
+		}
+		node = node.Content[index+1]
+		if isNull(node) && segments[i+1].isIndex {
+			node.Kind, node.Tag, node.Value = yaml.SequenceNode, "!!seq", ""
+		}
+	}
+	return nil
+}
+
+// Unset removes the dotted key. Removing a list item shifts the following items.
+func (d *Document) Unset(key string) error {
+	segments, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+
+	parent := d.document.Content[0]
+	for _, segment := range segments[:len(segments)-1] {
+		parent = child(parent, segment)
+		if parent == nil {
+			return fmt.Errorf("key '%s' is not set in %s", key, d.Path)
+		}
+	}
+
+	last := segments[len(segments)-1]
+	if last.isIndex {
+		if parent.Kind != yaml.SequenceNode || last.index >= len(parent.Content) {
+			return fmt.Errorf("key '%s' is not set in %s", key, d.Path)
+		}
+		parent.Content = append(parent.Content[:last.index], parent.Content[last.index+1:]...)
+		return nil
+	}
+
+	index := -1
+	if parent.Kind == yaml.MappingNode {
+		index = mappingIndex(parent, last.key)
+	}
+	if index < 0 {
+		return fmt.Errorf("key '%s' is not set in %s", key, d.Path)
+	}
+	parent.Content = append(parent.Content[:index], parent.Content[index+2:]...)
+	return nil
+}
+
+// Bytes encodes the document with two space indentation
+func (d *Document) Bytes() ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(d.document); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Validate checks the document against the schema without modifying it
+func (d *Document) Validate() ([]Issue, error) {
+	content, err := d.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return ValidateFile(d.Path, content)
+}
+
+// Save validates the document and writes it to its path. Nothing is written if the document has errors.
+func (d *Document) Save() ([]Issue, error) {
+	issues, err := d.Validate()
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		if !issue.Warning {
+			return issues, &ValidationError{Issues: issues}
+		}
+	}
+
+	content, err := d.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(d.Path), 0755); err != nil {
+		return nil, err
+	}
+	return issues, os.WriteFile(d.Path, content, 0644)
+}
+
+// ValidateFile checks the content of a single configuration file against the schema
+func ValidateFile(path string, content []byte) ([]Issue, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+	if document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse config file %s: expected a mapping at the top level", path)
+	}
+
+	layer := Layer{Path: path, node: &document}
+	issues := prepareLayer(&layer)
+	for _, issue := range issues {
+		if !issue.Warning {
+			return issues, nil
+		}
+	}
+
+	var config Config
+	if err := document.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	// Values the schema allows can still be invalid, e.g. a regex that does not compile
+	if err := config.Validate(); err != nil {
+		root := document.Content[0]
+		issues = append(issues, Issue{Path: path, Line: root.Line, Column: root.Column, Message: err.Error()})
+	}
+	return issues, nil
+}
+
+// EncodeValue renders a value for display: scalars as their plain value and everything else as YAML
+func EncodeValue(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value, nil
+	}
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buffer.String(), "\n"), nil
+}
+
+// LookupEffective returns the value at the dotted key in the merged configuration
+func LookupEffective(config *Config, key string) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(config); err != nil {
+		return nil, err
+	}
+	document := &Document{Path: "effective configuration", document: &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{&node}}}
+	return document.Get(key)
+}
+
+func parseKey(key string) ([]keySegment, error) {
+	if key == "" {
+		return nil, errors.New("key must not be empty")
+	}
+
+	var segments []keySegment
+	for _, part := range strings.Split(key, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name == "" && (len(segments) == 0 || rest == "") {
+			return nil, fmt.Errorf("invalid key '%s'", key)
+		}
+		if name != "" {
+			segments = append(segments, keySegment{key: name})
+		}
+		for rest != "" {
+			indexText, after, ok := strings.Cut(rest, "]")
+			if !ok {
+				return nil, fmt.Errorf("invalid key '%s': missing ']'", key)
+			}
+			index, err := strconv.Atoi(indexText)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid key '%s': '%s' is not a list index", key, indexText)
+			}
+			segments = append(segments, keySegment{index: index, isIndex: true})
+			if after != "" && !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("invalid key '%s'", key)
+			}
+			rest = strings.TrimPrefix(after, "[")
+		}
+	}
+	return segments, nil
+}
+
+func joinKey(segments []keySegment) string {
+	var b strings.Builder
+	for _, segment := range segments {
+		if segment.isIndex {
+			fmt.Fprintf(&b, "[%d]", segment.index)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(segment.key)
+	}
+	return b.String()
+}
+
+func child(node *yaml.Node, segment keySegment) *yaml.Node {
+	if segment.isIndex {
+		if node.Kind != yaml.SequenceNode || segment.index >= len(node.Content) {
+			return nil
+		}
+		return node.Content[segment.index]
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	if index := mappingIndex(node, segment.key); index >= 0 {
+		return node.Content[index+1]
+	}
+	return nil
+}
+
+func newContainer(next keySegment) *yaml.Node {
+	if next.isIndex {
+		return &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
+
+// parseValue turns a command line value into a node, keeping it a string where the schema expects one
+func parseValue(key string, segments []keySegment, value string) (*yaml.Node, error) {
+	if schema := schemaAt(JSONSchema(), segments); schema != nil && schema["type"] == "string" {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil {
+		return nil, fmt.Errorf("invalid value for '%s': %w", key, err)
+	}
+	if len(document.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	node := document.Content[0]
+	node.Line, node.Column = 0, 0
+	return node, nil
+}
+
+// schemaAt returns the schema of the value at the key, or nil if the schema does not describe it
+func schemaAt(schema Schema, segments []keySegment) Schema {
+	for _, segment := range segments {
+		if options, ok := schema["oneOf"].([]Schema); ok {
+			var next Schema
+			for _, option := range options {
+				if found := schemaAt(option, []keySegment{segment}); found != nil {
+					next = found
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			schema = next
+			continue
+		}
+		if segment.isIndex {
+			items, ok := schema["items"].(Schema)
+			if !ok {
+				return nil
+			}
+			schema = items
+			continue
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			if property, ok := properties[segment.key].(Schema); ok {
+				schema = property
+				continue
+			}
+		}
+		additional, ok := schema["additionalProperties"].(Schema)
+		if !ok {
+			return nil
+		}
+		schema = additional
+	}
+	return schema
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const commentedConfig = `# team config
+version: "1.1"
+defaults:
+  # base
+  base_branch: main # inline
+  copy_max_size: 1MB
+init_commands:
+  # install
+  - npm ci # first
+  - make
+`
+
+// edit is a config set (with a value) or config unset (without one)
+type edit struct {
+	key   string
+	value *string
+}
+
+func set(key, value string) edit {
+	return edit{key: key, value: &value}
+}
+
+func unset(key string) edit {
+	return edit{key: key}
+}
+
+func TestDocumentSetUnset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		edits   []edit
+		want    string
+	}{
+		{
+			name:    "replacing a value keeps its comments",
+			content: commentedConfig,
+			edits:   []edit{set("defaults.base_branch", "develop")},
+			want:    strings.Replace(commentedConfig, "base_branch: main # inline", "base_branch: develop # inline", 1),
+		},
+		{
+			name:    "replacing a list item keeps its comments",
+			content: commentedConfig,
+			edits:   []edit{set("init_commands[0]", "pnpm install")},
+			want:    strings.Replace(commentedConfig, "- npm ci # first", "- pnpm install # first", 1),
+		},
+		{
+			name:    "set and unset round trip",
+			content: commentedConfig,
+			edits: []edit{
+				set("defaults.open", "[tmux]"),
+				set("init_commands[2]", "make test"),
+				set("hooks.post_add[0].run", "echo hi"),
+				unset("hooks"),
+				unset("init_commands[2]"),
+				unset("defaults.open"),
+			},
+			want: commentedConfig,
+		},
+		{
+			name:    "new keys are appended to their mapping",
+			content: commentedConfig,
+			edits: []edit{
+				set("defaults.open", "[tmux]"),
+				set("init_commands[2]", "make test"),
+				set("hooks.post_add[0].run", "echo hi"),
+			},
+			want: `# team config
+version: "1.1"
+defaults:
+  # base
+  base_branch: main # inline
+  copy_max_size: 1MB
+  open: [tmux]
+init_commands:
+  # install
+  - npm ci # first
+  - make
+  - make test
+hooks:
+  post_add:
+    - run: echo hi
+`,
+		},
+		{
+			name:    "unsetting a list item shifts the following items",
+			content: commentedConfig,
+			edits:   []edit{unset("init_commands[0]")},
+			want: `# team config
+version: "1.1"
+defaults:
+  # base
+  base_branch: main # inline
+  copy_max_size: 1MB
+init_commands:
+  - make
+`,
+		},
+		{
+			name:    "strings stay strings",
+			content: commentedConfig,
+			edits:   []edit{set("defaults.base_branch", "1.0")},
+			want:    strings.Replace(commentedConfig, "base_branch: main # inline", `base_branch: "1.0" # inline`, 1),
+		},
+		{
+			name:    "an empty file starts at the current version",
+			content: "",
+			edits:   []edit{set("defaults.base_branch", "main")},
+			want:    "version: \"" + CurrentVersion + "\"\ndefaults:\n  base_branch: main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := parseDocument(".gwt.yml", []byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range tt.edits {
+				if e.value != nil {
+					err = document.Set(e.key, *e.value)
+				} else {
+					err = document.Unset(e.key)
+				}
+				if err != nil {
+					t.Fatalf("editing %s: %v", e.key, err)
+				}
+			}
+			got, err := document.Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("document =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentSetUnsetErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		edit    edit
+		wantErr string
+	}{
+		{name: "index out of range", edit: set("init_commands[5]", "make"), wantErr: "index 5 is out of range for 'init_commands' with 2 items"},
+		{name: "index into a mapping", edit: set("defaults[0]", "main"), wantErr: "'defaults' is not a list"},
+		{name: "key below a scalar", edit: set("defaults.base_branch.name", "main"), wantErr: "'defaults.base_branch' is not a mapping"},
+		{name: "unset a missing key", edit: unset("defaults.open"), wantErr: "key 'defaults.open' is not set in .gwt.yml"},
+		{name: "unset a missing item", edit: unset("init_commands[2]"), wantErr: "key 'init_commands[2]' is not set in .gwt.yml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, err := parseDocument(".gwt.yml", []byte(commentedConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.edit.value != nil {
+				err = document.Set(tt.edit.key, *tt.edit.value)
+			} else {
+				err = document.Unset(tt.edit.key)
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
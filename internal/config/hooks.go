@@ -0,0 +1,311 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Hook events, in the order they happen in a worktree's life
+const (
+	PreAdd     = "pre_add"
+	PostAdd    = "post_add"
+	PostSwitch = "post_switch"
+	PreRemove  = "pre_remove"
+	PostRemove = "post_remove"
+	PostClone  = "post_clone"
+)
+
+// HookEvents lists every hook event
+var HookEvents = []string{PreAdd, PostAdd, PostSwitch, PreRemove, PostRemove, PostClone}
+
+// BackgroundEvents lists the events whose hooks can run in the background
+var BackgroundEvents = []string{PostAdd, PostClone}
+
+// Hooks are the commands gwt runs on worktree lifecycle events
+type Hooks struct {
+	PreAdd     HookList `yaml:"pre_add,omitempty" desc:"Hooks to run before creating a worktree, in the repository root. A failing hook stops gwt add"`
+	PostAdd    HookList `yaml:"post_add,omitempty" desc:"Hooks to run after creating a worktree and copying files into it"`
+	PostSwitch HookList `yaml:"post_switch,omitempty" desc:"Hooks to run after switching to a worktree with gwt switch"`
+	PreRemove  HookList `yaml:"pre_remove,omitempty" desc:"Hooks to run before removing a worktree. A failing hook keeps the worktree"`
+	PostRemove HookList `yaml:"post_remove,omitempty" desc:"Hooks to run after removing a worktree, in the repository root"`
+	PostClone  HookList `yaml:"post_clone,omitempty" desc:"Hooks to run in the first worktree after gwt clone. Only the global config is loaded at that point"`
+	Jobs       int      `yaml:"jobs,omitempty" desc:"Number of parallel hooks run at the same time. Defaults to the number of CPUs"`
+	Notify     string   `yaml:"notify,omitempty" desc:"Command to run when background hooks finish, with GWT_SETUP_STATUS set to ready or failed"`
+}
+
+// Event returns the hooks of the event, or nil if the event does not exist
+func (h *Hooks) Event(event string) *HookList {
+	switch event {
+	case PreAdd:
+		return &h.PreAdd
+	case PostAdd:
+		return &h.PostAdd
+	case PostSwitch:
+		return &h.PostSwitch
+	case PreRemove:
+		return &h.PreRemove
+	case PostRemove:
+		return &h.PostRemove
+	case PostClone:
+		return &h.PostClone
+	}
+	return nil
+}
+
+func (h *Hooks) validate() error {
+	if h.Jobs < 0 {
+		return errors.New("jobs must not be negative")
+	}
+	for _, event := range HookEvents {
+		hooks := *h.Event(event)
+		if err := hooks.validate(event); err != nil {
+			return err
+		}
+		if slices.Contains(BackgroundEvents, event) {
+			continue
+		}
+		for i, hook := range hooks {
+			if hook.Background {
+				return fmt.Errorf("%s[%d]: only %s hooks can run in the background", event, i, strings.Join(BackgroundEvents, " and "))
+			}
+		}
+	}
+	return nil
+}
+
+// HookList is a single hook or a list of hooks
+type HookList []Hook
+
+func (l *HookList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		var hook Hook
+		if err := value.Decode(&hook); err != nil {
+			return err
+		}
+		*l = HookList{hook}
+		return nil
+	}
+	var hooks []Hook
+	if err := value.Decode(&hooks); err != nil {
+		return err
+	}
+	*l = hooks
+	return nil
+}
+
+// validate checks every hook, and that needs refer to named hooks of the same event without forming a cycle
+func (l HookList) validate(event string) error {
+	names := make(map[string]int)
+	for i, hook := range l {
+		if err := hook.validate(); err != nil {
+			return fmt.Errorf("%s[%d]: %w", event, i, err)
+		}
+		if hook.Name == "" {
+			continue
+		}
+		if j, ok := names[hook.Name]; ok {
+			return fmt.Errorf("%s[%d]: name '%s' is already used by %s[%d]", event, i, hook.Name, event, j)
+		}
+		names[hook.Name] = i
+	}
+
+	for i, hook := range l {
+		for _, need := range hook.Needs {
+			j, ok := names[need]
+			if !ok {
+				return fmt.Errorf("%s[%d]: needs '%s', which is not the name of a %s hook", event, i, need, event)
+			}
+			if l[j].Background && !hook.Background {
+				return fmt.Errorf("%s[%d]: needs '%s', which runs in the background", event, i, need)
+			}
+		}
+	}
+
+	// Depth-first search for a path that leads back to a hook on it
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(l))
+	var visit func(i int) error
+	visit = func(i int) error {
+		state[i] = visiting
+		for _, need := range l[i].Needs {
+			j := names[need]
+			if state[j] == visiting {
+				return fmt.Errorf("%s[%d]: needs '%s', which needs it in turn", event, i, need)
+			}
+			if state[j] == unvisited {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range l {
+		if state[i] == unvisited {
+			if err := visit(i); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Dependencies returns for each hook the indices of the hooks it waits for: its needs, the last sequential hook before
+// a parallel hook, or every hook before any other hook
+func (l HookList) Dependencies() [][]int {
+	names := make(map[string]int)
+	for i, hook := range l {
+		if hook.Name != "" {
+			names[hook.Name] = i
+		}
+	}
+
+	dependencies := make([][]int, len(l))
+	barrier := -1
+	for i, hook := range l {
+		switch {
+		case hook.Needs != nil:
+			for _, need := range hook.Needs {
+				dependencies[i] = append(dependencies[i], names[need])
+			}
+		case hook.Parallel:
+			if barrier >= 0 {
+				dependencies[i] = []int{barrier}
+			}
+		default:
+			for j := range i {
+				dependencies[i] = append(dependencies[i], j)
+			}
+			barrier = i
+		}
+	}
+	return dependencies
+}
+
+// Split separates the background hooks from the others, dropping their needs on hooks that finish before them
+func (l HookList) Split() (foreground HookList, background HookList) {
+	for _, hook := range l {
+		if !hook.Background {
+			foreground = append(foreground, hook)
+		}
+	}
+	for _, hook := range l {
+		if !hook.Background {
+			continue
+		}
+		if hook.Needs != nil {
+			needs := []string{}
+			for _, need := range hook.Needs {
+				if slices.ContainsFunc(foreground, func(h Hook) bool { return h.Name == need }) {
+					continue
+				}
+				needs = append(needs, need)
+			}
+			hook.Needs = needs
+		}
+		background = append(background, hook)
+	}
+	return foreground, background
+}
+
+// Concurrent reports whether any hook can run at the same time as another
+func (l HookList) Concurrent() bool {
+	for _, hook := range l {
+		if hook.Parallel || hook.Needs != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (HookList) JSONSchema() Schema {
+	hook := schemaFor(reflect.TypeOf(Hook{}))
+	return Schema{"oneOf": append([]Schema{{"type": "array", "items": hook}}, hook["oneOf"].([]Schema)...)}
+}
+
+// Hook is a command run on a lifecycle event, given as the command or as a mapping
+type Hook struct {
+	Name            string            `yaml:"name,omitempty" desc:"Name of the hook in its output and in needs of other hooks"`
+	Run             string            `yaml:"run" desc:"Command to run with sh. Go templates like {{.Branch}} are expanded"`
+	Dir             string            `yaml:"dir,omitempty" desc:"Directory to run the command in, relative to the worktree"`
+	Env             map[string]string `yaml:"env,omitempty" desc:"Environment variables of the command in addition to the GWT_* variables"`
+	If              HookCondition     `yaml:"if,omitempty" desc:"Conditions that must all hold for the hook to run"`
+	Timeout         string            `yaml:"timeout,omitempty" desc:"Duration after which the command is stopped, e.g. 30s or 5m"`
+	ContinueOnError bool              `yaml:"continue_on_error,omitempty" desc:"Carry on with the next hooks when the command fails"`
+	Parallel        bool              `yaml:"parallel,omitempty" desc:"Run at the same time as the parallel hooks next to it"`
+	Needs           []string          `yaml:"needs,omitempty" desc:"Names of the hooks that must finish before this one starts"`
+	Background      bool              `yaml:"background,omitempty" desc:"Run in a detached process after the other hooks. Only for post_add and post_clone"`
+}
+
+// HookCondition decides whether a hook runs
+type HookCondition struct {
+	Exists string `yaml:"exists,omitempty" desc:"Path relative to the worktree that must exist, e.g. package.json"`
+	Branch string `yaml:"branch,omitempty" desc:"Glob the branch must match, e.g. feature/*"`
+}
+
+// hookFields has the fields of Hook without its methods, to decode and describe the mapping form
+type hookFields Hook
+
+func (h *Hook) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*h = Hook{Run: value.Value}
+		return nil
+	}
+	return value.Decode((*hookFields)(h))
+}
+
+func (h Hook) MarshalYAML() (any, error) {
+	if h.Name == "" && h.Dir == "" && h.Env == nil && h.If == (HookCondition{}) && h.Timeout == "" && !h.ContinueOnError &&
+		!h.Parallel && h.Needs == nil && !h.Background {
+		return h.Run, nil
+	}
+	return hookFields(h), nil
+}
+
+func (Hook) JSONSchema() Schema {
+	return Schema{"oneOf": []Schema{
+		{"type": "string", "description": "Command to run"},
+		schemaFor(reflect.TypeOf(hookFields{})),
+	}}
+}
+
+func (h *Hook) validate() error {
+	if h.Run == "" {
+		return errors.New("run is required")
+	}
+	if h.Parallel && h.Needs != nil {
+		return errors.New("set either parallel or needs, not both")
+	}
+	if _, err := h.TimeoutDuration(); err != nil {
+		return err
+	}
+	if h.If.Branch != "" {
+		if _, err := path.Match(h.If.Branch, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s': %w", h.If.Branch, err)
+		}
+	}
+	return nil
+}
+
+// TimeoutDuration returns the parsed timeout, or zero if the hook has none
+func (h *Hook) TimeoutDuration() (time.Duration, error) {
+	if h.Timeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(h.Timeout)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("invalid timeout '%s'. Expected a duration like 30s or 5m", h.Timeout)
+	}
+	return timeout, nil
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHooksValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   Hooks
+		wantErr string // Substring of the error, empty when the hooks are valid
+	}{
+		{
+			name:  "valid hooks",
+			hooks: Hooks{PostAdd: HookList{{Run: "npm ci", Timeout: "5m", If: HookCondition{Branch: "feature/*"}}}},
+		},
+		{
+			name:    "missing run",
+			hooks:   Hooks{PostAdd: HookList{{Dir: "web"}}},
+			wantErr: "post_add[0]: run is required",
+		},
+		{
+			name:    "invalid timeout",
+			hooks:   Hooks{PreRemove: HookList{{Run: "make clean"}, {Run: "make clean", Timeout: "soon"}}},
+			wantErr: "pre_remove[1]: invalid timeout 'soon'. Expected a duration like 30s or 5m",
+		},
+		{
+			name:    "negative timeout",
+			hooks:   Hooks{PostAdd: HookList{{Run: "npm ci", Timeout: "-1s"}}},
+			wantErr: "invalid timeout '-1s'",
+		},
+		{
+			name:    "invalid branch glob",
+			hooks:   Hooks{PostSwitch: HookList{{Run: "nvm use", If: HookCondition{Branch: "["}}}},
+			wantErr: "post_switch[0]: invalid glob '['",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hooks.validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHookListValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   HookList
+		wantErr string // Substring of the error, empty when the hooks are valid
+	}{
+		{
+			name:  "no hooks",
+			hooks: nil,
+		},
+		{
+			name: "needs on named hooks",
+			hooks: HookList{
+				{Name: "install", Run: "npm ci"},
+				{Name: "build", Run: "npm run build", Needs: []string{"install"}},
+				{Run: "npm test", Needs: []string{"install", "build"}},
+			},
+		},
+		{
+			name:    "missing run",
+			hooks:   HookList{{Name: "empty"}},
+			wantErr: "post_add[0]: run is required",
+		},
+		{
+			name: "duplicate name",
+			hooks: HookList{
+				{Name: "install", Run: "npm ci"},
+				{Name: "install", Run: "pnpm install"},
+			},
+			wantErr: "post_add[1]: name 'install' is already used by post_add[0]",
+		},
+		{
+			name: "unknown need",
+			hooks: HookList{
+				{Name: "build", Run: "npm run build", Needs: []string{"install"}},
+			},
+			wantErr: "post_add[0]: needs 'install', which is not the name of a post_add hook",
+		},
+		{
+			name: "need on itself",
+			hooks: HookList{
+				{Name: "build", Run: "npm run build", Needs: []string{"build"}},
+			},
+			wantErr: "which needs it in turn",
+		},
+		{
+			name: "cycle",
+			hooks: HookList{
+				{Name: "a", Run: "echo a", Needs: []string{"c"}},
+				{Name: "b", Run: "echo b", Needs: []string{"a"}},
+				{Name: "c", Run: "echo c", Needs: []string{"b"}},
+			},
+			wantErr: "which needs it in turn",
+		},
+		{
+			name: "foreground hook needs a background hook",
+			hooks: HookList{
+				{Name: "index", Run: "ctags -R", Background: true},
+				{Run: "echo done", Needs: []string{"index"}},
+			},
+			wantErr: "post_add[1]: needs 'index', which runs in the background",
+		},
+		{
+			name: "parallel and needs",
+			hooks: HookList{
+				{Name: "install", Run: "npm ci"},
+				{Run: "npm test", Parallel: true, Needs: []string{"install"}},
+			},
+			wantErr: "post_add[1]: set either parallel or needs, not both",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.hooks.validate(PostAdd)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHooksValidateBackground(t *testing.T) {
+	hooks := Hooks{PreRemove: HookList{{Run: "make clean", Background: true}}}
+	err := hooks.validate()
+	if err == nil || !strings.Contains(err.Error(), "pre_remove[0]: only post_add and post_clone hooks can run in the background") {
+		t.Fatalf("validate() = %v, want an error about background pre_remove hooks", err)
+	}
+
+	hooks = Hooks{PostClone: HookList{{Run: "make", Background: true}}, Jobs: 2}
+	if err := hooks.validate(); err != nil {
+		t.Fatalf("validate() = %v, want no error", err)
+	}
+}
+
+func TestHookListDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks HookList
+		want  [][]int
+	}{
+		{
+			name:  "sequential hooks wait for every hook before them",
+			hooks: HookList{{Run: "a"}, {Run: "b"}, {Run: "c"}},
+			want:  [][]int{nil, {0}, {0, 1}},
+		},
+		{
+			name:  "parallel hooks wait for the last sequential hook",
+			hooks: HookList{{Run: "a"}, {Run: "b", Parallel: true}, {Run: "c", Parallel: true}, {Run: "d"}},
+			want:  [][]int{nil, {0}, {0}, {0, 1, 2}},
+		},
+		{
+			name:  "leading parallel hooks wait for nothing",
+			hooks: HookList{{Run: "a", Parallel: true}, {Run: "b", Parallel: true}},
+			want:  [][]int{nil, nil},
+		},
+		{
+			name: "needs wait for the named hooks only",
+			hooks: HookList{
+				{Name: "install", Run: "npm ci"},
+				{Name: "lint", Run: "npm run lint", Needs: []string{}},
+				{Name: "build", Run: "npm run build", Needs: []string{"install"}},
+				{Run: "npm test", Needs: []string{"build", "install"}},
+			},
+			want: [][]int{nil, nil, {0}, {2, 0}},
+		},
+		{
+			name: "needs do not move the barrier of parallel hooks",
+			hooks: HookList{
+				{Name: "install", Run: "npm ci"},
+				{Run: "npm run build", Needs: []string{"install"}},
+				{Run: "npm test", Parallel: true},
+			},
+			want: [][]int{nil, {0}, {0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hooks.Dependencies(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Dependencies() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
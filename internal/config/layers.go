@@ -0,0 +1,318 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	GlobalConfigFileName = "config.yml"
+	LocalConfigFileName  = ".gwt.local.yml"
+
+	// AppendTag marks a list in a higher layer that is appended to the lower layers instead of replacing them
+	AppendTag = "!append"
+)
+
+// Layer names in increasing order of precedence
+const (
+	LayerGlobal   = "global"
+	LayerRepo     = "repo"
+	LayerLocal    = "local"
+	LayerExplicit = "flag"
+)
+
+// Layer is a single configuration file that takes part in the merged configuration
+type Layer struct {
+	Name string
+	Path string
+	node *yaml.Node // Document node, nil when the file does not exist
+}
+
+// Origin is where an effective configuration value was defined
+type Origin struct {
+	Layer string `json:"layer"`
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+}
+
+func (o Origin) String() string {
+	return fmt.Sprintf("%s %s:%d", o.Layer, o.Path, o.Line)
+}
+
+// Origins maps dotted keys, e.g. defaults.base_branch or init_commands[2], to where they were defined
+type Origins map[string]Origin
+
+var explicitPath string
+
+// SetExplicitPath adds the file passed with --config as the layer with the highest precedence
+func SetExplicitPath(path string) {
+	explicitPath = path
+}
+
+// GlobalConfigPath returns $XDG_CONFIG_HOME/gwt/config.yml, falling back to ~/.config/gwt/config.yml
+func GlobalConfigPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "gwt", GlobalConfigFileName), nil
+}
+
+// LayerPaths returns every configuration layer in increasing order of precedence, whether or not the file exists.
+// The repository layers are omitted when repoRoot is empty.
+func LayerPaths(repoRoot string) ([]Layer, error) {
+	var layers []Layer
+
+	globalPath, err := GlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	layers = append(layers, Layer{Name: LayerGlobal, Path: globalPath})
+
+	if repoRoot != "" {
+		layers = append(layers,
+			Layer{Name: LayerRepo, Path: filepath.Join(repoRoot, ConfigFileName)},
+			Layer{Name: LayerLocal, Path: filepath.Join(repoRoot, LocalConfigFileName)},
+		)
+	}
+
+	if explicitPath != "" {
+		path, err := filepath.Abs(explicitPath)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Name: LayerExplicit, Path: path})
+	}
+
+	return layers, nil
+}
+
+// loadLayers reads every existing configuration layer. The file passed with --config must exist.
+func loadLayers(repoRoot string) ([]Layer, error) {
+	layers, err := LayerPaths(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := make([]Layer, 0, len(layers))
+	for _, layer := range layers {
+		content, err := os.ReadFile(layer.Path)
+		if errors.Is(err, os.ErrNotExist) && layer.Name != LayerExplicit {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		var document yaml.Node
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", layer.Path, err)
+		}
+		if len(document.Content) == 0 {
+			continue
+		}
+		if document.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("failed to parse config file %s: expected a mapping at the top level", layer.Path)
+		}
+		layer.node = &document
+		loaded = append(loaded, layer)
+	}
+	return loaded, nil
+}
+
+// commandKeyPattern matches the keys of settings that run commands, at the top level or in a rule
+var commandKeyPattern = regexp.MustCompile(`^(rules\[\d+\]\.)?(init_commands|destroy_commands|hooks)([.\[]|$)`)
+
+// ruleMatcherPattern matches the keys that select the branches of a rule
+var ruleMatcherPattern = regexp.MustCompile(`^(rules\[\d+\]\.)(match|regex)$`)
+
+// RepoCommands returns the settings of the repository's .gwt.yml that run commands, one "key: value" line per value in
+// document order, e.g. "hooks.post_add[0].run: npm install". Rules that run commands also contribute their match and
+// regex, so it is clear which branches the commands run for. With trackedLocal, .gwt.local.yml is committed to the
+// repository as well, so its lines follow, prefixed with its file name. The other layers belong to the user and are
+// left out.
+func RepoCommands(repoRoot string, trackedLocal bool) ([]string, error) {
+	layers, err := loadLayers(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for i := range layers {
+		switch {
+		case layers[i].Name == LayerRepo:
+			lines = append(lines, layerCommands(&layers[i], "")...)
+		case layers[i].Name == LayerLocal && trackedLocal:
+			lines = append(lines, layerCommands(&layers[i], LocalConfigFileName+" ")...)
+		}
+	}
+	return lines, nil
+}
+
+// layerCommands returns the lines of RepoCommands for a single layer, each starting with prefix
+func layerCommands(layer *Layer, prefix string) []string {
+	// Migrations and deprecated keys are applied, so older files list the keys they are read as
+	prepareLayer(layer)
+
+	var keys []string
+	values := make(map[string]string)
+	rulesWithCommands := make(map[string]bool)
+	walkKeys(layer.node.Content[0], "", func(key string, value *yaml.Node) {
+		keys = append(keys, key)
+		switch value.Kind {
+		case yaml.SequenceNode:
+			values[key] = "[]"
+		case yaml.MappingNode:
+			values[key] = "{}"
+		default:
+			values[key] = strings.TrimSpace(value.Value)
+			if strings.Contains(values[key], "\n") {
+				values[key] = strconv.Quote(values[key])
+			}
+		}
+		if match := commandKeyPattern.FindStringSubmatch(key); match != nil && match[1] != "" {
+			rulesWithCommands[match[1]] = true
+		}
+	})
+
+	var lines []string
+	for _, key := range keys {
+		match := ruleMatcherPattern.FindStringSubmatch(key)
+		if commandKeyPattern.MatchString(key) || (match != nil && rulesWithCommands[match[1]]) {
+			lines = append(lines, prefix+key+": "+values[key])
+		}
+	}
+	return lines
+}
+
+// mergeLayers merges the layers in order of precedence and records the origin of every value in the result
+func mergeLayers(layers []Layer) (*yaml.Node, Origins) {
+	nodeLayers := make(map[*yaml.Node]*Layer)
+	var merged *yaml.Node
+	for i := range layers {
+		root := layers[i].node.Content[0]
+		walkNodes(root, func(n *yaml.Node) { nodeLayers[n] = &layers[i] })
+		if merged == nil {
+			merged = mergeNodes(nil, root)
+		} else {
+			merged = mergeNodes(merged, root)
+		}
+	}
+	if merged == nil {
+		merged = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	}
+
+	origins := make(Origins)
+	walkKeys(merged, "", func(key string, value *yaml.Node) {
+		if layer, ok := nodeLayers[value]; ok {
+			origins[key] = Origin{Layer: layer.Name, Path: layer.Path, Line: value.Line}
+		}
+	})
+	return merged, origins
+}
+
+// mergeNodes returns override merged on top of base. Mappings are merged key by key, lists tagged !append are
+// appended to the list in base, and everything else replaces the value in base.
+func mergeNodes(base, override *yaml.Node) *yaml.Node {
+	if override.Kind == yaml.MappingNode && (base == nil || base.Kind == yaml.MappingNode) {
+		merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Line: override.Line, Column: override.Column}
+		if base != nil {
+			merged.Content = append(merged.Content, base.Content...)
+		}
+		for i := 0; i+1 < len(override.Content); i += 2 {
+			key, value := override.Content[i], override.Content[i+1]
+			if existing := mappingIndex(merged, key.Value); existing >= 0 {
+				merged.Content[existing+1] = mergeNodes(merged.Content[existing+1], value)
+			} else {
+				merged.Content = append(merged.Content, key, mergeNodes(nil, value))
+			}
+		}
+		return merged
+	}
+
+	if override.Kind == yaml.SequenceNode && override.Tag == AppendTag {
+		merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: override.Style, Line: override.Line, Column: override.Column}
+		if base != nil && base.Kind == yaml.SequenceNode {
+			merged.Content = append(merged.Content, base.Content...)
+		}
+		merged.Content = append(merged.Content, override.Content...)
+		return merged
+	}
+
+	return override
+}
+
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func walkNodes(n *yaml.Node, fn func(n *yaml.Node)) {
+	fn(n)
+	for _, child := range n.Content {
+		walkNodes(child, fn)
+	}
+}
+
+// walkKeys calls fn for every leaf value with its dotted key, e.g. defaults.base_branch or init_commands[2]
+func walkKeys(n *yaml.Node, prefix string, fn func(key string, value *yaml.Node)) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 && prefix != "" {
+			fn(prefix, n)
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walkKeys(n.Content[i+1], key, fn)
+		}
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			fn(prefix, n)
+		}
+		for i, child := range n.Content {
+			walkKeys(child, fmt.Sprintf("%s[%d]", prefix, i), fn)
+		}
+	default:
+		fn(prefix, n)
+	}
+}
+
+// FlattenConfig returns every leaf of the configuration as dotted keys and values, in document order
+func FlattenConfig(c *Config) ([]string, map[string]string, error) {
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, nil, err
+	}
+
+	var keys []string
+	values := make(map[string]string)
+	walkKeys(&node, "", func(key string, value *yaml.Node) {
+		keys = append(keys, key)
+		switch value.Kind {
+		case yaml.SequenceNode:
+			values[key] = "[]"
+		case yaml.MappingNode:
+			values[key] = "{}"
+		default:
+			values[key] = strings.TrimSpace(value.Value)
+		}
+	})
+	return keys, values, nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRepoCommands(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	repoRoot := t.TempDir()
+	files := map[string]string{
+		ConfigFileName: `version: "1.1"
+defaults:
+  base_branch: main
+init_commands: [npm ci]
+rules:
+  - match: docs/*
+    open: [webstorm]
+  - match: release/*
+    destroy_commands: [make clean]
+`,
+		LocalConfigFileName: `hooks:
+  post_add:
+    - run: curl https://example.com/install.sh | sh
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(repoRoot, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repoLines := []string{
+		"init_commands[0]: npm ci",
+		"rules[1].match: release/*",
+		"rules[1].destroy_commands[0]: make clean",
+	}
+	tests := []struct {
+		name         string
+		trackedLocal bool
+		want         []string
+	}{
+		{name: "untracked local config is left out", want: repoLines},
+		{
+			name:         "tracked local config is included",
+			trackedLocal: true,
+			want: append(repoLines,
+				".gwt.local.yml hooks.post_add[0].run: curl https://example.com/install.sh | sh",
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepoCommands(repoRoot, tt.trackedLocal)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RepoCommands() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// DefaultBranchTemplate names branches created from free text after their type, e.g. fix/login-redirect
+const DefaultBranchTemplate = "{{.Type}}/{{.Slug}}"
+
+// BranchNaming is the convention new branch names must follow
+type BranchNaming struct {
+	Regex     string   `yaml:"regex,omitempty" desc:"Regular expression every new branch name must match, e.g. ^(feature|fix)/[a-z0-9-]+$"`
+	Prefixes  []string `yaml:"prefixes,omitempty" desc:"Prefixes new branch names must start with, e.g. feature/ or fix/"`
+	MaxLength int      `yaml:"max_length,omitempty" desc:"Maximum length of new branch names"`
+	Slugify   bool     `yaml:"slugify,omitempty" desc:"Lowercase new branch names and replace spaces and other unusual characters with dashes instead of rejecting them"`
+	Template  string   `yaml:"template,omitempty" desc:"Template of branch names created from free text with gwt add --type, using .Type, .Slug and .Text. Defaults to {{.Type}}/{{.Slug}}"`
+
+	regex    *regexp.Regexp
+	template *template.Template
+}
+
+// BranchNameData is the data available to branch_naming.template
+type BranchNameData struct {
+	Type string // Type passed with --type, e.g. fix
+	Slug string // Slug of the text without a leading word equal to the type, e.g. login-redirect
+	Text string // Text as given, e.g. Fix login redirect
+}
+
+// validate compiles the regular expression and the template
+func (n *BranchNaming) validate() error {
+	if n.Regex != "" {
+		regex, err := regexp.Compile(n.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex '%s': %w", n.Regex, err)
+		}
+		n.regex = regex
+	}
+	if n.MaxLength < 0 {
+		return errors.New("max_length must not be negative")
+	}
+	text := n.Template
+	if text == "" {
+		text = DefaultBranchTemplate
+	}
+	tmpl, err := template.New("template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, BranchNameData{Type: "fix", Slug: "login-redirect", Text: "Fix login redirect"}); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+	n.template = tmpl
+	return nil
+}
+
+// Check returns an error describing the first rule the branch name breaks
+func (n *BranchNaming) Check(branch string) error {
+	if len(n.Prefixes) > 0 && !n.hasPrefix(branch) {
+		return fmt.Errorf("branch name '%s' must start with one of %s", branch, strings.Join(n.Prefixes, ", "))
+	}
+	if n.MaxLength > 0 && len(branch) > n.MaxLength {
+		return fmt.Errorf("branch name '%s' is %d characters long, longer than the maximum of %d", branch, len(branch), n.MaxLength)
+	}
+	if n.Regex == "" {
+		return nil
+	}
+	matched := false
+	if n.regex != nil {
+		matched = n.regex.MatchString(branch)
+	} else {
+		matched, _ = regexp.MatchString(n.Regex, branch)
+	}
+	if !matched {
+		return fmt.Errorf("branch name '%s' does not match %s", branch, n.Regex)
+	}
+	return nil
+}
+
+func (n *BranchNaming) hasPrefix(branch string) bool {
+	for _, prefix := range n.Prefixes {
+		if strings.HasPrefix(branch, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Types returns the prefixes without their trailing slash, for completing --type
+func (n *BranchNaming) Types() []string {
+	types := make([]string, 0, len(n.Prefixes))
+	for _, prefix := range n.Prefixes {
+		types = append(types, strings.TrimSuffix(prefix, "/"))
+	}
+	return types
+}
+
+// Normalize lowercases each component of the branch name, replaces runs of unusual characters with a dash and drops
+// empty components, e.g. "Fix Login//Page" becomes fix-login/page
+func (n *BranchNaming) Normalize(branch string) string {
+	var components []string
+	for _, component := range strings.Split(branch, "/") {
+		if component = slugify(strings.ToLower(component)); component != "" {
+			components = append(components, component)
+		}
+	}
+	return strings.Join(components, "/")
+}
+
+// FromText turns free text into a branch name of the type with the template. A leading word equal to the type is
+// dropped, so "Fix login redirect" of type fix becomes fix/login-redirect, and words are dropped from the end until
+// the name fits max_length.
+func (n *BranchNaming) FromText(branchType string, text string) (string, error) {
+	tmpl := n.template
+	if tmpl == nil {
+		if err := n.validate(); err != nil {
+			return "", err
+		}
+		tmpl = n.template
+	}
+
+	slug := slugify(strings.ToLower(strings.ReplaceAll(text, "/", " ")))
+	if rest, ok := strings.CutPrefix(slug, strings.ToLower(branchType)+"-"); ok {
+		slug = rest
+	}
+	if slug == "" {
+		return "", fmt.Errorf("'%s' does not contain any letters or digits to name a branch after", text)
+	}
+
+	for {
+		var name strings.Builder
+		if err := tmpl.Execute(&name, BranchNameData{Type: branchType, Slug: slug, Text: text}); err != nil {
+			return "", fmt.Errorf("invalid branch_naming.template: %w", err)
+		}
+		i := strings.LastIndex(slug, "-")
+		if n.MaxLength <= 0 || name.Len() <= n.MaxLength || i < 0 {
+			return name.String(), nil
+		}
+		slug = slug[:i]
+	}
+}
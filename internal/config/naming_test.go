@@ -0,0 +1,123 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBranchNamingCheck(t *testing.T) {
+	naming := BranchNaming{Regex: `^(feature|fix)/[a-z0-9-]+$`, Prefixes: []string{"feature/", "fix/"}, MaxLength: 20}
+	if err := naming.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		branch  string
+		wantErr string // Substring of the error, empty when the name follows the rules
+	}{
+		{branch: "feature/login"},
+		{branch: "fix/a-b-c"},
+		{branch: "chore/deps", wantErr: "branch name 'chore/deps' must start with one of feature/, fix/"},
+		{branch: "feature/a-very-long-name", wantErr: "is 24 characters long, longer than the maximum of 20"},
+		{branch: "feature/Login", wantErr: "branch name 'feature/Login' does not match ^(feature|fix)/[a-z0-9-]+$"},
+		{branch: "fix/", wantErr: "does not match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			err := naming.Check(tt.branch)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check() = %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Check() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := (&BranchNaming{}).Check("anything/Goes Here"); err != nil {
+		t.Errorf("Check() without rules = %v, want no error", err)
+	}
+}
+
+func TestBranchNamingValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		naming  BranchNaming
+		wantErr string
+	}{
+		{name: "invalid regex", naming: BranchNaming{Regex: "("}, wantErr: "invalid regex '('"},
+		{name: "negative max_length", naming: BranchNaming{MaxLength: -1}, wantErr: "max_length must not be negative"},
+		{name: "unknown template field", naming: BranchNaming{Template: "{{.Kind}}/{{.Slug}}"}, wantErr: "invalid template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.naming.validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validate() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBranchNamingNormalize(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{branch: "feature/login", want: "feature/login"},
+		{branch: "Fix Login//Page", want: "fix-login/page"},
+		{branch: "feature/Add OAuth (v2)!", want: "feature/add-oauth-v2"},
+		{branch: "/feature/ login /", want: "feature/login"},
+	}
+
+	var naming BranchNaming
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := naming.Normalize(tt.branch); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchNamingFromText(t *testing.T) {
+	tests := []struct {
+		name       string
+		naming     BranchNaming
+		branchType string
+		text       string
+		want       string
+		wantErr    string
+	}{
+		{name: "default template", branchType: "fix", text: "Login redirect", want: "fix/login-redirect"},
+		{name: "leading type word is dropped", branchType: "fix", text: "Fix login redirect", want: "fix/login-redirect"},
+		{name: "slashes become dashes", branchType: "feature", text: "API/v2 client", want: "feature/api-v2-client"},
+		{name: "words are dropped to fit", naming: BranchNaming{MaxLength: 16}, branchType: "fix", text: "login redirect loop", want: "fix/login"},
+		{name: "a single word is kept", naming: BranchNaming{MaxLength: 4}, branchType: "fix", text: "login", want: "fix/login"},
+		{name: "custom template", naming: BranchNaming{Template: "{{.Type}}-{{.Slug}}"}, branchType: "chore", text: "Bump deps", want: "chore-bump-deps"},
+		{name: "no letters or digits", branchType: "fix", text: "?!", wantErr: "does not contain any letters or digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.naming.FromText(tt.branchType, tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FromText() = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("FromText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// LatestTagBaseBranch is a base branch that resolves to the latest tag reachable from defaults.base_branch
+const LatestTagBaseBranch = "@latest-tag"
+
+// Rule overrides settings for branches matching a glob or regular expression. Every matching rule applies in order,
+// so later rules override earlier ones.
+type Rule struct {
+	Match           string   `yaml:"match,omitempty" desc:"Glob matched against the branch name, e.g. release/*"`
+	Regex           string   `yaml:"regex,omitempty" desc:"Regular expression matched against the branch name, e.g. ^hotfix/"`
+	BaseBranch      string   `yaml:"base_branch,omitempty" desc:"Base branch for new worktrees of matching branches, or @latest-tag"`
+	InitCommands    []string `yaml:"init_commands,omitempty" desc:"Commands to run after creating a worktree, replacing init_commands"`
+	DestroyCommands []string `yaml:"destroy_commands,omitempty" desc:"Commands to run before removing a worktree, replacing destroy_commands"`
+	Open            []string `yaml:"open,omitempty" desc:"Tools to open new worktrees with, replacing defaults.open" enum:"sesh,webstorm,idea,pycharm,clion,rider,goland,datagrip"`
+	WorktreePath    string   `yaml:"worktree_path,omitempty" desc:"Template of the path of new worktrees, replacing defaults.worktree_path"`
+	Copy            []string `yaml:"copy,omitempty" desc:"Globs of files to copy into new worktrees, replacing copy"`
+	Symlink         []string `yaml:"symlink,omitempty" desc:"Globs of files to symlink into new worktrees, replacing symlink"`
+	Hooks           Hooks    `yaml:"hooks,omitempty" desc:"Hooks of matching branches. Each event replaces the hooks of the same event"`
+
+	regex *regexp.Regexp
+}
+
+// validate compiles the rule's pattern and checks that exactly one of match and regex is set
+func (r *Rule) validate() error {
+	switch {
+	case r.Match != "" && r.Regex != "":
+		return errors.New("set either match or regex, not both")
+	case r.Match != "":
+		if _, err := path.Match(r.Match, ""); err != nil {
+			return fmt.Errorf("invalid glob '%s': %w", r.Match, err)
+		}
+	case r.Regex != "":
+		regex, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex '%s': %w", r.Regex, err)
+		}
+		r.regex = regex
+	default:
+		return errors.New("match or regex is required")
+	}
+	if r.WorktreePath != "" {
+		if _, err := parseWorktreePath(r.WorktreePath); err != nil {
+			return err
+		}
+	}
+	if err := r.Hooks.validate(); err != nil {
+		return fmt.Errorf("hooks.%w", err)
+	}
+	return nil
+}
+
+// Matches reports whether the rule applies to the branch
+func (r *Rule) Matches(branch string) bool {
+	if branch == "" {
+		return false
+	}
+	if r.regex != nil {
+		return r.regex.MatchString(branch)
+	}
+	if r.Regex != "" {
+		matched, _ := regexp.MatchString(r.Regex, branch)
+		return matched
+	}
+	matched, _ := path.Match(r.Match, branch)
+	return matched
+}
+
+// Pattern returns the glob or regular expression of the rule for display, e.g. "match release/*"
+func (r *Rule) Pattern() string {
+	if r.Regex != "" {
+		return "regex " + r.Regex
+	}
+	return "match " + r.Match
+}
+
+// BranchConfig is the configuration that applies to a single branch after rules are applied
+type BranchConfig struct {
+	Branch          string
+	BaseBranch      string
+	InitCommands    []string
+	DestroyCommands []string
+	Open            []string
+	WorktreePath    string
+	Copy            []string
+	Symlink         []string
+	Hooks           Hooks
+
+	// Matched holds the indices of the rules that matched, in order
+	Matched []int
+	// Sources maps each setting to where its value comes from, e.g. "defaults" or "rules[2]"
+	Sources map[string]string
+}
+
+// ForBranch applies the rules matching branch on top of the defaults
+func (c *Config) ForBranch(branch string) *BranchConfig {
+	bc := &BranchConfig{
+		Branch:          branch,
+		BaseBranch:      c.Defaults.BaseBranch,
+		InitCommands:    c.InitCommands,
+		DestroyCommands: c.DestroyCommands,
+		Open:            c.Defaults.Open,
+		WorktreePath:    c.Defaults.WorktreePath,
+		Copy:            c.Copy,
+		Symlink:         c.Symlink,
+		Hooks:           c.Hooks,
+		Sources: map[string]string{
+			"copy":             "defaults",
+			"symlink":          "defaults",
+			"base_branch":      "defaults",
+			"init_commands":    "defaults",
+			"destroy_commands": "defaults",
+			"open":             "defaults",
+			"worktree_path":    "defaults",
+			"hooks.jobs":       "defaults",
+			"hooks.notify":     "defaults",
+		},
+	}
+	for _, event := range HookEvents {
+		bc.Sources["hooks."+event] = "defaults"
+	}
+
+	for i := range c.Rules {
+		rule := &c.Rules[i]
+		if !rule.Matches(branch) {
+			continue
+		}
+		bc.Matched = append(bc.Matched, i)
+		source := fmt.Sprintf("rules[%d]", i)
+		if rule.BaseBranch != "" {
+			bc.BaseBranch = rule.BaseBranch
+			bc.Sources["base_branch"] = source
+		}
+		if rule.InitCommands != nil {
+			bc.InitCommands = rule.InitCommands
+			bc.Sources["init_commands"] = source
+		}
+		if rule.DestroyCommands != nil {
+			bc.DestroyCommands = rule.DestroyCommands
+			bc.Sources["destroy_commands"] = source
+		}
+		if rule.Open != nil {
+			bc.Open = rule.Open
+			bc.Sources["open"] = source
+		}
+		if rule.WorktreePath != "" {
+			bc.WorktreePath = rule.WorktreePath
+			bc.Sources["worktree_path"] = source
+		}
+		if rule.Copy != nil {
+			bc.Copy = rule.Copy
+			bc.Sources["copy"] = source
+		}
+		if rule.Symlink != nil {
+			bc.Symlink = rule.Symlink
+			bc.Sources["symlink"] = source
+		}
+		if rule.Hooks.Jobs > 0 {
+			bc.Hooks.Jobs = rule.Hooks.Jobs
+			bc.Sources["hooks.jobs"] = source
+		}
+		if rule.Hooks.Notify != "" {
+			bc.Hooks.Notify = rule.Hooks.Notify
+			bc.Sources["hooks.notify"] = source
+		}
+		for _, event := range HookEvents {
+			if hooks := *rule.Hooks.Event(event); hooks != nil {
+				*bc.Hooks.Event(event) = hooks
+				bc.Sources["hooks."+event] = source
+			}
+		}
+	}
+	return bc
+}
+
+// WorktreePathData is the data available to worktree_path templates
+type WorktreePathData struct {
+	Repo       string // Name of the repository root directory
+	Root       string // Absolute path of the repository root
+	Branch     string // Branch name, e.g. feature/login
+	BranchSlug string // Branch name with every run of characters other than letters, digits, dots, dashes and underscores replaced by a dash, e.g. feature-login
+}
+
+var worktreePathFuncs = template.FuncMap{
+	"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"lower":   strings.ToLower,
+	"upper":   strings.ToUpper,
+	"slug":    slugify,
+}
+
+var slugPattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func slugify(s string) string {
+	return strings.Trim(slugPattern.ReplaceAllString(s, "-"), "-.")
+}
+
+// unsafePathChars are characters that are valid in branch names but not in paths on every platform
+var unsafePathChars = regexp.MustCompile(`[\x00-\x1f"<>|:*?\\]`)
+
+func parseWorktreePath(text string) (*template.Template, error) {
+	tmpl, err := template.New("worktree_path").Funcs(worktreePathFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid worktree_path: %w", err)
+	}
+	return tmpl, nil
+}
+
+// ResolveWorktreePath renders the worktree_path template for the branch. Relative paths are relative to the repository
+// root and may point outside of it. Without a template the worktree is named after the branch in the repository root.
+func (bc *BranchConfig) ResolveWorktreePath(repoRoot string) (string, error) {
+	return ResolveWorktreePath(bc.WorktreePath, repoRoot, bc.Branch)
+}
+
+// ResolveWorktreePath renders a worktree_path template for the branch
+func ResolveWorktreePath(worktreePath string, repoRoot string, branch string) (string, error) {
+	repoRoot = filepath.Clean(repoRoot)
+	if worktreePath == "" {
+		worktreePath = DefaultWorktreePath
+	}
+
+	tmpl, err := parseWorktreePath(worktreePath)
+	if err != nil {
+		return "", err
+	}
+	data := WorktreePathData{
+		Repo:       filepath.Base(repoRoot),
+		Root:       repoRoot,
+		Branch:     unsafePathChars.ReplaceAllString(branch, "-"),
+		BranchSlug: slugify(branch),
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, data); err != nil {
+		return "", fmt.Errorf("invalid worktree_path: %w", err)
+	}
+
+	// Drop empty and surrounding whitespace components so a template never yields a hidden or blank directory
+	var components []string
+	for _, component := range strings.Split(filepath.ToSlash(rendered.String()), "/") {
+		component = strings.TrimSpace(component)
+		if component != "" && component != "." {
+			components = append(components, component)
+		}
+	}
+	if len(components) == 0 {
+		return "", fmt.Errorf("worktree_path '%s' is empty for branch '%s'", worktreePath, branch)
+	}
+
+	resolved := filepath.Join(components...)
+	if filepath.IsAbs(rendered.String()) {
+		resolved = filepath.Join(string(filepath.Separator), resolved)
+	} else {
+		resolved = filepath.Join(repoRoot, resolved)
+	}
+
+	if isWithin(resolved, repoRoot) {
+		return "", fmt.Errorf("worktree_path '%s' resolves to %s, which contains the repository root", worktreePath, resolved)
+	}
+	for _, reserved := range []string{".git", ".bare"} {
+		if isWithin(filepath.Join(repoRoot, reserved), resolved) {
+			return "", fmt.Errorf("worktree_path '%s' resolves to %s, inside the git directory", worktreePath, resolved)
+		}
+	}
+	return resolved, nil
+}
+
+// isWithin reports whether path is dir or inside it. Both paths must be absolute and clean.
+func isWithin(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestForBranch(t *testing.T) {
+	config := &Config{
+		Defaults:     Defaults{BaseBranch: "main"},
+		InitCommands: []string{"npm ci"},
+		Rules: []Rule{
+			{Match: "release/*", BaseBranch: LatestTagBaseBranch, InitCommands: []string{}},
+			{Regex: "^(feature|fix)/", BaseBranch: "develop"},
+			{Match: "feature/ui-*", Open: []string{"webstorm"}, WorktreePath: "../ui/{{.BranchSlug}}"},
+		},
+	}
+	for i := range config.Rules {
+		if err := config.Rules[i].validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		branch      string
+		wantMatched []int
+		wantBase    string
+		wantInit    []string
+		wantOpen    []string
+		wantPath    string
+		wantSources map[string]string // Sources that are not defaults
+	}{
+		{branch: "main", wantBase: "main", wantInit: []string{"npm ci"}},
+		{branch: "", wantBase: "main", wantInit: []string{"npm ci"}},
+		{
+			branch:      "release/1.2",
+			wantMatched: []int{0},
+			wantBase:    LatestTagBaseBranch,
+			wantInit:    []string{},
+			wantSources: map[string]string{"base_branch": "rules[0]", "init_commands": "rules[0]"},
+		},
+		{
+			branch:      "feature/ui-login",
+			wantMatched: []int{1, 2},
+			wantBase:    "develop",
+			wantInit:    []string{"npm ci"},
+			wantOpen:    []string{"webstorm"},
+			wantPath:    "../ui/{{.BranchSlug}}",
+			wantSources: map[string]string{"base_branch": "rules[1]", "open": "rules[2]", "worktree_path": "rules[2]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			bc := config.ForBranch(tt.branch)
+			if !reflect.DeepEqual(bc.Matched, tt.wantMatched) {
+				t.Errorf("matched = %v, want %v", bc.Matched, tt.wantMatched)
+			}
+			if bc.BaseBranch != tt.wantBase {
+				t.Errorf("base_branch = %q, want %q", bc.BaseBranch, tt.wantBase)
+			}
+			if !reflect.DeepEqual(bc.InitCommands, tt.wantInit) {
+				t.Errorf("init_commands = %q, want %q", bc.InitCommands, tt.wantInit)
+			}
+			if !reflect.DeepEqual(bc.Open, tt.wantOpen) {
+				t.Errorf("open = %q, want %q", bc.Open, tt.wantOpen)
+			}
+			if bc.WorktreePath != tt.wantPath {
+				t.Errorf("worktree_path = %q, want %q", bc.WorktreePath, tt.wantPath)
+			}
+			for setting, source := range bc.Sources {
+				want, ok := tt.wantSources[setting]
+				if !ok {
+					want = "defaults"
+				}
+				if source != want {
+					t.Errorf("source of %s = %q, want %q", setting, source, want)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveWorktreePath(t *testing.T) {
+	tests := []struct {
+		name         string
+		worktreePath string
+		branch       string
+		want         string
+		wantErr      string // Substring of the error, empty when the path resolves
+	}{
+		{name: "default is the branch in the root", branch: "main", want: "/work/repo/main"},
+		{name: "slashes make directories", branch: "feature/login", want: "/work/repo/feature/login"},
+		{name: "unsafe characters are replaced", branch: `fix/a:b*c?"d"<e>|f\g`, want: "/work/repo/fix/a-b-c--d--e--f-g"},
+		{name: "slug", worktreePath: "{{.BranchSlug}}", branch: "feature/Login Page!", want: "/work/repo/feature-Login-Page"},
+		{name: "sibling directory", worktreePath: "../{{.Repo}}-worktrees/{{.Branch}}", branch: "feature/login", want: "/work/repo-worktrees/feature/login"},
+		{name: "absolute path", worktreePath: "/tmp/{{.Repo}}/{{slug .Branch}}", branch: "feature/login", want: "/tmp/repo/feature-login"},
+		{name: "template functions", worktreePath: `{{lower .Branch | replace "/" "_"}}`, branch: "Feature/Login", want: "/work/repo/feature_login"},
+		{name: "empty and dot components are dropped", worktreePath: "wt//./ {{.Branch}} /", branch: "main", want: "/work/repo/wt/main"},
+		{name: "empty result", worktreePath: "{{if false}}x{{end}}", branch: "main", wantErr: "is empty for branch 'main'"},
+		{name: "only dots and spaces", worktreePath: " ./ . /", branch: "main", wantErr: "is empty for branch 'main'"},
+		{name: "repository root", worktreePath: "..", branch: "main", wantErr: "which contains the repository root"},
+		{name: "parent of the root", worktreePath: "../..", branch: "main", wantErr: "which contains the repository root"},
+		{name: "root through the parent", worktreePath: "../repo", branch: "main", wantErr: "which contains the repository root"},
+		{name: "git directory", worktreePath: ".git/{{.Branch}}", branch: "main", wantErr: "inside the git directory"},
+		{name: "bare directory", worktreePath: ".bare", branch: "main", wantErr: "inside the git directory"},
+		{name: "branch named like the git directory", branch: ".bare/main", wantErr: "inside the git directory"},
+		{name: "similar name is not reserved", worktreePath: ".bare-{{.Branch}}", branch: "main", want: "/work/repo/.bare-main"},
+		{name: "unknown field", worktreePath: "{{.Nope}}", branch: "main", wantErr: "invalid worktree_path"},
+		{name: "invalid template", worktreePath: "{{.Branch", branch: "main", wantErr: "invalid worktree_path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveWorktreePath(tt.worktreePath, "/work/repo/", tt.branch)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ResolveWorktreePath() = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveWorktreePath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveWorktreePath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForBranchHooks(t *testing.T) {
+	config := &Config{
+		Hooks: Hooks{PostAdd: HookList{{Run: "npm ci"}}, PreRemove: HookList{{Run: "make clean"}}, Jobs: 2},
+		Rules: []Rule{
+			{Match: "feature/*", Hooks: Hooks{PostAdd: HookList{{Run: "make"}}, Jobs: 4}},
+			{Match: "feature/ui-*", Hooks: Hooks{Notify: "notify-send done"}},
+		},
+	}
+
+	bc := config.ForBranch("feature/ui-login")
+	if !reflect.DeepEqual(bc.Hooks.PostAdd, HookList{{Run: "make"}}) || !reflect.DeepEqual(bc.Hooks.PreRemove, config.Hooks.PreRemove) {
+		t.Errorf("hooks = %+v, want post_add from the rule and pre_remove from the defaults", bc.Hooks)
+	}
+	if bc.Hooks.Jobs != 4 || bc.Hooks.Notify != "notify-send done" {
+		t.Errorf("jobs = %d, notify = %q, want 4 and notify-send done", bc.Hooks.Jobs, bc.Hooks.Notify)
+	}
+	want := map[string]string{"hooks.post_add": "rules[0]", "hooks.pre_remove": "defaults", "hooks.jobs": "rules[0]", "hooks.notify": "rules[1]"}
+	for setting, source := range want {
+		if bc.Sources[setting] != source {
+			t.Errorf("source of %s = %q, want %q", setting, bc.Sources[setting], source)
+		}
+	}
+}
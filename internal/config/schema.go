@@ -0,0 +1,98 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+const schemaURL = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema document
+type Schema map[string]any
+
+// schemaProvider is implemented by configuration types whose schema cannot be derived from their fields
+type schemaProvider interface {
+	JSONSchema() Schema
+}
+
+// JSONSchema returns the JSON Schema of the configuration file, derived from the yaml, desc and enum tags of Config
+func JSONSchema() Schema {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	schema["$schema"] = schemaURL
+	schema["title"] = "gwt configuration"
+
+	properties := schema["properties"].(map[string]any)
+	for alias, key := range deprecatedKeys {
+		if target, ok := properties[key]; ok {
+			deprecated := Schema{"deprecated": true, "description": "Deprecated alias of " + key}
+			for k, v := range target.(Schema) {
+				if k != "description" {
+					deprecated[k] = v
+				}
+			}
+			properties[alias] = deprecated
+		}
+	}
+	return schema
+}
+
+func schemaFor(t reflect.Type) Schema {
+	if provider, ok := reflect.New(t).Interface().(schemaProvider); ok {
+		return provider.JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]any)
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name := yamlFieldName(field)
+			if name == "" {
+				continue
+			}
+			property := schemaFor(field.Type)
+			if desc := field.Tag.Get("desc"); desc != "" {
+				property["description"] = desc
+			}
+			if enum := field.Tag.Get("enum"); enum != "" {
+				if items, ok := property["items"].(Schema); ok {
+					items["enum"] = strings.Split(enum, ",")
+				} else {
+					property["enum"] = strings.Split(enum, ",")
+				}
+			}
+			properties[name] = property
+		}
+		return Schema{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	default:
+		return Schema{"type": "string"}
+	}
+}
+
+// yamlFieldName returns the key of a struct field in YAML, or an empty string if it is not serialized
+func yamlFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
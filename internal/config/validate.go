@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Issue is a problem found in a configuration file
+type Issue struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	Warning bool   `json:"warning"`
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s", i.Path, i.Line, i.Column, i.Message)
+}
+
+// ValidationError is returned when a configuration file contains errors
+type ValidationError struct {
+	Issues []Issue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Issues))
+	for _, issue := range e.Issues {
+		if !issue.Warning {
+			lines = append(lines, issue.String())
+		}
+	}
+	return "invalid configuration:\n" + strings.Join(lines, "\n")
+}
+
+// deprecatedKeys maps deprecated top-level keys to the keys that replace them
+var deprecatedKeys = map[string]string{
+	"remove_commands": "destroy_commands",
+}
+
+// migration upgrades a configuration file from one schema version to the next
+type migration struct {
+	from    string
+	to      string
+	migrate func(root *yaml.Node, report func(n *yaml.Node, message string, warning bool))
+}
+
+var migrations = []migration{
+	{
+		// gwt init used to write remove_commands, which was never read
+		from: "1.0",
+		to:   "1.1",
+		migrate: func(root *yaml.Node, report func(n *yaml.Node, message string, warning bool)) {
+			renameKey(root, "remove_commands", "destroy_commands", report)
+		},
+	},
+}
+
+// prepareLayer migrates the layer to the current schema version in memory and checks it against the schema
+func prepareLayer(layer *Layer) []Issue {
+	var issues []Issue
+	// A migration and a deprecated key can both find the same problem, e.g. remove_commands next to destroy_commands
+	report := func(n *yaml.Node, message string, warning bool) {
+		issue := Issue{Path: layer.Path, Line: n.Line, Column: n.Column, Message: message, Warning: warning}
+		if !slices.Contains(issues, issue) {
+			issues = append(issues, issue)
+		}
+	}
+
+	root := layer.node.Content[0]
+	version := CurrentVersion
+	versionNode := root
+	if i := mappingIndex(root, "version"); i >= 0 {
+		versionNode = root.Content[i+1]
+		version = versionNode.Value
+	} else {
+		// Files without a version predate versioning
+		version = migrations[0].from
+	}
+
+	current, err := compareVersions(version, CurrentVersion)
+	if err != nil {
+		report(versionNode, err.Error(), false)
+		return issues
+	}
+	if current > 0 {
+		report(versionNode, fmt.Sprintf("config version %s is newer than the supported version %s. Please upgrade gwt", version, CurrentVersion), false)
+		return issues
+	}
+
+	for _, m := range migrations {
+		if cmp, _ := compareVersions(version, m.from); cmp <= 0 {
+			m.migrate(root, report)
+			version = m.to
+		}
+	}
+	for alias, key := range deprecatedKeys {
+		renameKey(root, alias, key, report)
+	}
+	if versionNode != root {
+		versionNode.Value = CurrentVersion
+	}
+
+	checkNode(root, JSONSchema(), "", report)
+	return issues
+}
+
+// renameKey renames a deprecated top-level key, reporting a warning, or an error if both keys are set
+func renameKey(root *yaml.Node, from, to string, report func(n *yaml.Node, message string, warning bool)) {
+	i := mappingIndex(root, from)
+	if i < 0 {
+		return
+	}
+	if mappingIndex(root, to) >= 0 {
+		report(root.Content[i], fmt.Sprintf("both '%s' and its deprecated alias '%s' are set. Remove '%s'", to, from, from), false)
+		return
+	}
+	report(root.Content[i], fmt.Sprintf("'%s' is deprecated, use '%s' instead", from, to), true)
+	root.Content[i].Value = to
+}
+
+// checkNode reports nodes that do not match the schema, such as unknown keys or values of the wrong type
+func checkNode(n *yaml.Node, schema Schema, key string, report func(n *yaml.Node, message string, warning bool)) {
+	if options, ok := schema["oneOf"].([]Schema); ok {
+		for _, option := range options {
+			if nodeMatchesType(n, option["type"]) {
+				checkNode(n, option, key, report)
+				return
+			}
+		}
+		report(n, fmt.Sprintf("'%s' has an unsupported value", key), false)
+		return
+	}
+
+	if !nodeMatchesType(n, schema["type"]) {
+		report(n, fmt.Sprintf("'%s' must be %s", key, describeType(schema["type"])), false)
+		return
+	}
+
+	if values, ok := schema["enum"].([]string); ok && n.Kind == yaml.ScalarNode && !slices.Contains(values, n.Value) {
+		report(n, fmt.Sprintf("'%s' must be one of %s", key, strings.Join(values, ", ")), false)
+		return
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(Schema)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			keyNode, valueNode := n.Content[i], n.Content[i+1]
+			childKey := keyNode.Value
+			if key != "" {
+				childKey = key + "." + keyNode.Value
+			}
+			if property, ok := properties[keyNode.Value]; ok {
+				checkNode(valueNode, property.(Schema), childKey, report)
+			} else if additional != nil {
+				checkNode(valueNode, additional, childKey, report)
+			} else {
+				report(keyNode, unknownKeyMessage(childKey, keyNode.Value, properties), false)
+			}
+		}
+	case yaml.SequenceNode:
+		if items, ok := schema["items"].(Schema); ok {
+			for i, item := range n.Content {
+				checkNode(item, items, fmt.Sprintf("%s[%d]", key, i), report)
+			}
+		}
+	}
+}
+
+func nodeMatchesType(n *yaml.Node, schemaType any) bool {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch schemaType {
+	case "object":
+		return n.Kind == yaml.MappingNode || isNull(n)
+	case "array":
+		return n.Kind == yaml.SequenceNode || isNull(n)
+	case "boolean":
+		return n.Kind == yaml.ScalarNode && (n.ShortTag() == "!!bool" || isNull(n))
+	case "integer":
+		return n.Kind == yaml.ScalarNode && (n.ShortTag() == "!!int" || isNull(n))
+	case "number":
+		return n.Kind == yaml.ScalarNode && (n.ShortTag() == "!!int" || n.ShortTag() == "!!float" || isNull(n))
+	case "string":
+		return n.Kind == yaml.ScalarNode
+	default:
+		return true
+	}
+}
+
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
+
+func describeType(schemaType any) string {
+	switch schemaType {
+	case "object":
+		return "a mapping"
+	case "array":
+		return "a list"
+	case "boolean":
+		return "true or false"
+	case "integer":
+		return "an integer"
+	case "number":
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+func unknownKeyMessage(fullKey, key string, properties map[string]any) string {
+	message := fmt.Sprintf("unknown key '%s'", fullKey)
+	best, bestDistance := "", 4
+	for candidate, property := range properties {
+		if deprecated, _ := property.(Schema)["deprecated"].(bool); deprecated {
+			continue
+		}
+		if distance := levenshtein(key, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best != "" {
+		message += fmt.Sprintf(". Did you mean '%s'?", best)
+	} else if len(properties) > 0 {
+		keys := make([]string, 0, len(properties))
+		for candidate, property := range properties {
+			if deprecated, _ := property.(Schema)["deprecated"].(bool); !deprecated {
+				keys = append(keys, candidate)
+			}
+		}
+		slices.Sort(keys)
+		message += fmt.Sprintf(". Valid keys: %s", strings.Join(keys, ", "))
+	}
+	return message
+}
+
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// compareVersions compares two "major.minor" versions
+func compareVersions(a, b string) (int, error) {
+	aMajor, aMinor, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	bMajor, bMinor, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+	if aMajor != bMajor {
+		return aMajor - bMajor, nil
+	}
+	return aMinor - bMinor, nil
+}
+
+func parseVersion(version string) (int, int, error) {
+	majorText, minorText, _ := strings.Cut(version, ".")
+	major, err := strconv.Atoi(majorText)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid config version '%s'. Expected a version like %s", version, CurrentVersion)
+	}
+	minor := 0
+	if minorText != "" {
+		if minor, err = strconv.Atoi(minorText); err != nil {
+			return 0, 0, fmt.Errorf("invalid config version '%s'. Expected a version like %s", version, CurrentVersion)
+		}
+	}
+	return major, minor, nil
+}
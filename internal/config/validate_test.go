@@ -0,0 +1,122 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPrepareLayerMigrations(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantVersion  string   // Version of the config after preparing it, empty when the file has none
+		wantDestroy  []string // destroy_commands after preparing the config
+		wantWarnings []string
+		wantErrors   []string
+	}{
+		{
+			name:        "1.0 renames remove_commands",
+			content:     "version: \"1.0\"\nremove_commands:\n  - docker compose down\n",
+			wantVersion: "1.1",
+			wantDestroy: []string{"docker compose down"},
+			wantWarnings: []string{
+				"'remove_commands' is deprecated, use 'destroy_commands' instead",
+			},
+		},
+		{
+			name:        "no version is migrated like 1.0",
+			content:     "remove_commands: [make clean]\n",
+			wantDestroy: []string{"make clean"},
+			wantWarnings: []string{
+				"'remove_commands' is deprecated, use 'destroy_commands' instead",
+			},
+		},
+		{
+			name:        "1.0 without remove_commands",
+			content:     "version: \"1.0\"\ndestroy_commands: [make clean]\n",
+			wantVersion: "1.1",
+			wantDestroy: []string{"make clean"},
+		},
+		{
+			name:        "1.0 with both keys",
+			content:     "version: \"1.0\"\nremove_commands: [a]\ndestroy_commands: [b]\n",
+			wantVersion: "1.1",
+			wantDestroy: []string{"b"},
+			wantErrors: []string{
+				"both 'destroy_commands' and its deprecated alias 'remove_commands' are set. Remove 'remove_commands'",
+			},
+		},
+		{
+			name:        "1.1 still accepts the deprecated key",
+			content:     "version: \"1.1\"\nremove_commands: [a]\n",
+			wantVersion: "1.1",
+			wantDestroy: []string{"a"},
+			wantWarnings: []string{
+				"'remove_commands' is deprecated, use 'destroy_commands' instead",
+			},
+		},
+		{
+			name:        "newer version",
+			content:     "version: \"2.0\"\n",
+			wantVersion: "2.0",
+			wantErrors: []string{
+				"config version 2.0 is newer than the supported version 1.1. Please upgrade gwt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var document yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.content), &document); err != nil {
+				t.Fatal(err)
+			}
+			layer := Layer{Path: ".gwt.yml", node: &document}
+
+			var warnings, errs []string
+			for _, issue := range prepareLayer(&layer) {
+				if issue.Warning {
+					warnings = append(warnings, issue.Message)
+				} else {
+					errs = append(errs, issue.Message)
+				}
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+			}
+			if !reflect.DeepEqual(errs, tt.wantErrors) {
+				t.Errorf("errors = %q, want %q", errs, tt.wantErrors)
+			}
+
+			var config struct {
+				Version         string   `yaml:"version"`
+				DestroyCommands []string `yaml:"destroy_commands"`
+			}
+			if err := document.Decode(&config); err != nil {
+				t.Fatal(err)
+			}
+			if config.Version != tt.wantVersion {
+				t.Errorf("version = %q, want %q", config.Version, tt.wantVersion)
+			}
+			if !reflect.DeepEqual(config.DestroyCommands, tt.wantDestroy) {
+				t.Errorf("destroy_commands = %q, want %q", config.DestroyCommands, tt.wantDestroy)
+			}
+		})
+	}
+}
+
+func TestPrepareLayerInvalidVersion(t *testing.T) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte("version: one\n"), &document); err != nil {
+		t.Fatal(err)
+	}
+	issues := prepareLayer(&Layer{Path: ".gwt.yml", node: &document})
+	if len(issues) != 1 || issues[0].Warning || !strings.Contains(issues[0].Message, "one") {
+		t.Fatalf("prepareLayer() = %+v, want one error about the version", issues)
+	}
+	if issues[0].Line != 1 || issues[0].Column != 10 {
+		t.Errorf("issue at %d:%d, want 1:10", issues[0].Line, issues[0].Column)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jcelaya775/gwt/internal/shell"
+	"github.com/jcelaya775/gwt/internal/utils"
 	"os/exec"
 	"runtime"
+	"slices"
+	"strings"
 )
 
 type Connector struct {
@@ -16,6 +19,59 @@ func New(shell shell.Shell) *Connector {
 	return &Connector{shell: shell}
 }
 
+// Opener opens a worktree directory with a tool
+type Opener func(dir string) error
+
+// Sesh is the name of the opener that connects to the worktree's tmux session
+const Sesh = "sesh"
+
+// OpenerNames lists the names of the openers in the order they run when several are requested
+var OpenerNames = []string{Sesh, string(WebStorm), string(IntelliJIDEA), string(PyCharm), string(CLion), string(Rider), string(GoLand), string(DataGrip)}
+
+// Openers returns every opener by the name used in the config and the --open flag
+func (c *Connector) Openers() map[string]Opener {
+	return map[string]Opener{
+		Sesh:                 c.SeshConnect,
+		string(WebStorm):     c.WebstormConnect,
+		string(IntelliJIDEA): c.IntelliJConnect,
+		string(PyCharm):      c.PyCharmConnect,
+		string(CLion):        c.CLionConnect,
+		string(Rider):        c.RiderConnect,
+		string(GoLand):       c.GoLandConnect,
+		string(DataGrip):     c.DataGripConnect,
+	}
+}
+
+// ValidateOpeners returns an error naming the first unknown opener
+func ValidateOpeners(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(OpenerNames, name) {
+			return fmt.Errorf("unknown opener '%s'. Valid openers: %s", name, strings.Join(OpenerNames, ", "))
+		}
+	}
+	return nil
+}
+
+// Open opens dir with each named opener in the order of OpenerNames
+func (c *Connector) Open(names []string, dir string) error {
+	if err := ValidateOpeners(names); err != nil {
+		return err
+	}
+	openers := c.Openers()
+	for _, name := range OpenerNames {
+		if slices.Contains(names, name) {
+			if utils.DryRun() {
+				utils.RecordDryRun("open %s with %s", utils.ShellQuote(dir), name)
+				continue
+			}
+			if err := openers[name](dir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Connector) SeshConnect(dir string) error {
 	_, err := c.shell.Cmd("sesh", "connect", dir)
 	return err
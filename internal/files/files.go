@@ -0,0 +1,368 @@
+package files
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Mode is how copied files are written to the new worktree
+type Mode string
+
+const (
+	// ModeAuto clones files with copy-on-write when the file system supports it and falls back to a full copy
+	ModeAuto Mode = "auto"
+	// ModeReflink only clones files with copy-on-write and fails on file systems without support
+	ModeReflink Mode = "reflink"
+	// ModeHardlink links files to the source, so changes in one worktree show up in the other
+	ModeHardlink Mode = "hardlink"
+	// ModeCopy always writes a full copy
+	ModeCopy Mode = "copy"
+)
+
+// Action is what happened to a matched path
+type Action string
+
+const (
+	ActionCopied     Action = "copied"
+	ActionCloned     Action = "cloned"
+	ActionHardlinked Action = "hardlinked"
+	ActionSymlinked  Action = "symlinked"
+	ActionExists     Action = "exists"
+	ActionSkipped    Action = "skipped"
+)
+
+// ErrReflinkUnsupported is returned when the file system cannot clone files with copy-on-write
+var ErrReflinkUnsupported = errors.New("the file system does not support copy-on-write clones")
+
+// Options describes what Apply copies and symlinks from one worktree into another
+type Options struct {
+	Source  string   // Worktree the files come from
+	Target  string   // New worktree
+	Copy    []string // Globs relative to the worktree, ** matches any number of directories
+	Symlink []string // Globs relative to the worktree, ** matches any number of directories
+	Mode    Mode
+	MaxSize int64 // Largest total size written with a full copy before further matches are skipped
+	DryRun  bool  // Report what would be written without writing anything
+}
+
+// Entry is a single matched path and what happened to it
+type Entry struct {
+	Path   string `json:"path"`
+	Action Action `json:"action"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report lists every path Apply matched, in the order of the globs
+type Report struct {
+	Source      string  `json:"source"`
+	Entries     []Entry `json:"entries"`
+	CopiedBytes int64   `json:"copied_bytes"` // Bytes written with a full copy
+}
+
+// Apply copies and symlinks the files matching the globs from the source worktree into the target worktree. Paths that
+// already exist in the target, such as tracked files, are left alone. In a dry run the report's actions are what the
+// mode would do, counting every file auto mode copies as a full copy.
+func Apply(opts Options) (*Report, error) {
+	report := &Report{Source: opts.Source}
+	if opts.Mode == "" {
+		opts.Mode = ModeAuto
+	}
+
+	// Once a clone succeeds the file system supports copy-on-write, and the size guard no longer applies
+	cloned := false
+	for _, pattern := range opts.Copy {
+		matches, err := Glob(opts.Source, pattern)
+		if err != nil {
+			return report, err
+		}
+		for _, match := range matches {
+			target := filepath.Join(opts.Target, match)
+			if _, err := os.Lstat(target); err == nil {
+				report.Entries = append(report.Entries, Entry{Path: match, Action: ActionExists})
+				continue
+			}
+
+			size, err := pathSize(filepath.Join(opts.Source, match))
+			if err != nil {
+				return report, err
+			}
+			fullCopy := opts.Mode == ModeCopy || (opts.Mode == ModeAuto && !cloned)
+			if fullCopy && opts.MaxSize > 0 && report.CopiedBytes+size > opts.MaxSize {
+				report.Entries = append(report.Entries, Entry{Path: match, Action: ActionSkipped, Size: size,
+					Reason: "exceeds copy_max_size. Symlink it or raise the limit"})
+				continue
+			}
+
+			if opts.DryRun {
+				action := plannedAction(opts.Mode)
+				if action == ActionCopied {
+					report.CopiedBytes += size
+				}
+				report.Entries = append(report.Entries, Entry{Path: match, Action: action, Size: size})
+				continue
+			}
+
+			action, copiedBytes, err := copyPath(filepath.Join(opts.Source, match), target, opts.Mode)
+			if err != nil {
+				return report, fmt.Errorf("failed to copy %s: %w", match, err)
+			}
+			if action == ActionCloned {
+				cloned = true
+			}
+			report.CopiedBytes += copiedBytes
+			report.Entries = append(report.Entries, Entry{Path: match, Action: action, Size: size})
+		}
+	}
+
+	for _, pattern := range opts.Symlink {
+		matches, err := Glob(opts.Source, pattern)
+		if err != nil {
+			return report, err
+		}
+		for _, match := range matches {
+			target := filepath.Join(opts.Target, match)
+			if _, err := os.Lstat(target); err == nil {
+				report.Entries = append(report.Entries, Entry{Path: match, Action: ActionExists})
+				continue
+			}
+			if opts.DryRun {
+				report.Entries = append(report.Entries, Entry{Path: match, Action: ActionSymlinked})
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return report, err
+			}
+			if err := os.Symlink(filepath.Join(opts.Source, match), target); err != nil {
+				return report, fmt.Errorf("failed to symlink %s: %w", match, err)
+			}
+			report.Entries = append(report.Entries, Entry{Path: match, Action: ActionSymlinked})
+		}
+	}
+
+	return report, nil
+}
+
+// plannedAction is how the mode writes files, assuming a full copy where auto mode could clone them
+func plannedAction(mode Mode) Action {
+	switch mode {
+	case ModeHardlink:
+		return ActionHardlinked
+	case ModeReflink:
+		return ActionCloned
+	default:
+		return ActionCopied
+	}
+}
+
+// Glob returns the paths in root matching the pattern, relative to root. ** matches any number of directories, and
+// matched directories are not searched further. The .git entry is never matched.
+func Glob(root string, pattern string) ([]string, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid glob '%s': %w", pattern, err)
+	}
+	if pattern == ".." || strings.HasPrefix(pattern, "../") || strings.Contains(pattern, "/../") {
+		return nil, fmt.Errorf("invalid glob '%s': must not leave the worktree", pattern)
+	}
+	segments := strings.Split(pattern, "/")
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ".git" {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matchSegments(segments, strings.Split(rel, "/")) {
+			matches = append(matches, filepath.FromSlash(rel))
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() && !canMatchBelow(segments, strings.Split(rel, "/")) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return matches, err
+}
+
+// matchSegments matches path segments against pattern segments, where a ** segment matches zero or more segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// canMatchBelow reports whether a path inside the directory could match the pattern, to avoid walking unrelated trees
+func canMatchBelow(pattern, dir []string) bool {
+	for i, segment := range dir {
+		if i >= len(pattern) {
+			return false
+		}
+		if pattern[i] == "**" {
+			return true
+		}
+		if matched, _ := path.Match(pattern[i], segment); !matched {
+			return false
+		}
+	}
+	return len(pattern) > len(dir)
+}
+
+func pathSize(p string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(p, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// copyPath copies a file, symlink or directory tree. It returns how the files were written and how many bytes were
+// written with a full copy.
+func copyPath(src, dst string, mode Mode) (Action, int64, error) {
+	actions := make(map[Action]bool)
+	var copiedBytes int64
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			action, err := copyFile(p, target, info.Mode().Perm(), mode)
+			if err != nil {
+				return err
+			}
+			actions[action] = true
+			if action == ActionCopied {
+				copiedBytes += info.Size()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", copiedBytes, err
+	}
+
+	// Report the weakest action, so a directory where any file needed a full copy shows up as copied
+	for _, action := range []Action{ActionCopied, ActionHardlinked, ActionCloned} {
+		if actions[action] {
+			return action, copiedBytes, nil
+		}
+	}
+	return ActionCopied, copiedBytes, nil
+}
+
+func copyFile(src, dst string, perm fs.FileMode, mode Mode) (Action, error) {
+	switch mode {
+	case ModeHardlink:
+		if err := os.Link(src, dst); err != nil {
+			return "", err
+		}
+		return ActionHardlinked, nil
+	case ModeReflink, ModeAuto:
+		err := reflink(src, dst, perm)
+		if err == nil {
+			return ActionCloned, nil
+		}
+		if mode == ModeReflink || !errors.Is(err, ErrReflinkUnsupported) {
+			return "", err
+		}
+	}
+
+	if err := fullCopy(src, dst, perm); err != nil {
+		return "", err
+	}
+	return ActionCopied, nil
+}
+
+func fullCopy(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,234 @@
+package files
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates the files with the given contents below root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: ".env", path: ".env", want: true},
+		{pattern: ".env", path: "api/.env"},
+		{pattern: "*.env", path: "local.env", want: true},
+		{pattern: "**/.env", path: ".env", want: true},
+		{pattern: "**/.env", path: "services/api/.env", want: true},
+		{pattern: "**", path: "a/b/c", want: true},
+		{pattern: "config/**/*.local.json", path: "config/app.local.json", want: true},
+		{pattern: "config/**/*.local.json", path: "config/a/b/app.local.json", want: true},
+		{pattern: "config/**/*.local.json", path: "other/app.local.json"},
+		{pattern: "a/*", path: "a/b/c"},
+		{pattern: "a/b/c", path: "a/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			got := matchSegments(strings.Split(tt.pattern, "/"), strings.Split(tt.path, "/"))
+			if got != tt.want {
+				t.Errorf("matchSegments(%q, %q) = %t, want %t", tt.pattern, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanMatchBelow(t *testing.T) {
+	tests := []struct {
+		pattern string
+		dir     string
+		want    bool
+	}{
+		{pattern: "config/*.json", dir: "config", want: true},
+		{pattern: "config/*.json", dir: "src"},
+		{pattern: "config/*.json", dir: "config/nested"},
+		{pattern: "**/.env", dir: "a", want: true},
+		{pattern: "services/**/.env", dir: "services/api/deep", want: true},
+		{pattern: "*/.env", dir: "api", want: true},
+		{pattern: ".env", dir: "api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.dir, func(t *testing.T) {
+			got := canMatchBelow(strings.Split(tt.pattern, "/"), strings.Split(tt.dir, "/"))
+			if got != tt.want {
+				t.Errorf("canMatchBelow(%q, %q) = %t, want %t", tt.pattern, tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGlob(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".env":                    "",
+		"api/.env":                "",
+		"api/node_modules/x/.env": "",
+		"web/src/.env":            "",
+		".git/.env":               "",
+		"config/app.local.json":   "",
+		"config/app.json":         "",
+	})
+
+	tests := []struct {
+		pattern string
+		want    []string
+		wantErr string
+	}{
+		{pattern: ".env", want: []string{".env"}},
+		{pattern: "/.env", want: []string{".env"}},
+		{pattern: "**/.env", want: []string{".env", "api/.env", "api/node_modules/x/.env", "web/src/.env"}},
+		{pattern: "*/.env", want: []string{"api/.env"}},
+		{pattern: "config/*.local.json", want: []string{"config/app.local.json"}},
+		{pattern: "**/node_modules", want: []string{"api/node_modules"}},
+		{pattern: "missing"},
+		{pattern: "../secrets", wantErr: "must not leave the worktree"},
+		{pattern: "a/../../secrets", wantErr: "must not leave the worktree"},
+		{pattern: "[", wantErr: "invalid glob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := Glob(root, tt.pattern)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Glob() = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want []string
+			for _, p := range tt.want {
+				want = append(want, filepath.FromSlash(p))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Glob() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeFiles(t, source, map[string]string{
+		".env":              "SECRET=1\n",
+		"tracked.txt":       "source\n",
+		"cache/a.bin":       strings.Repeat("a", 6),
+		"cache/b.bin":       strings.Repeat("b", 6),
+		"node_modules/x.js": "x\n",
+	})
+	writeFiles(t, target, map[string]string{"tracked.txt": "target\n"})
+
+	report, err := Apply(Options{
+		Source:  source,
+		Target:  target,
+		Copy:    []string{".env", "tracked.txt", "cache/*.bin"},
+		Symlink: []string{"node_modules"},
+		Mode:    ModeCopy,
+		MaxSize: 16,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Entry{
+		{Path: ".env", Action: ActionCopied, Size: 9},
+		{Path: "tracked.txt", Action: ActionExists},
+		{Path: filepath.Join("cache", "a.bin"), Action: ActionCopied, Size: 6},
+		{Path: filepath.Join("cache", "b.bin"), Action: ActionSkipped, Size: 6, Reason: "exceeds copy_max_size. Symlink it or raise the limit"},
+		{Path: "node_modules", Action: ActionSymlinked},
+	}
+	if !reflect.DeepEqual(report.Entries, want) {
+		t.Errorf("entries = %+v, want %+v", report.Entries, want)
+	}
+	if report.CopiedBytes != 15 {
+		t.Errorf("copied bytes = %d, want 15", report.CopiedBytes)
+	}
+
+	if content, err := os.ReadFile(filepath.Join(target, ".env")); err != nil || string(content) != "SECRET=1\n" {
+		t.Errorf(".env = %q, %v, want a copy", content, err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(target, "tracked.txt")); string(content) != "target\n" {
+		t.Errorf("tracked.txt = %q, want it left alone", content)
+	}
+	if _, err := os.Lstat(filepath.Join(target, "cache", "b.bin")); !os.IsNotExist(err) {
+		t.Errorf("cache/b.bin was written past the size guard")
+	}
+	if link, err := os.Readlink(filepath.Join(target, "node_modules")); err != nil || link != filepath.Join(source, "node_modules") {
+		t.Errorf("node_modules links to %q, %v, want %q", link, err, filepath.Join(source, "node_modules"))
+	}
+}
+
+func TestApplyDryRun(t *testing.T) {
+	source, target := t.TempDir(), t.TempDir()
+	writeFiles(t, source, map[string]string{
+		"a.bin":             strings.Repeat("a", 6),
+		"b.bin":             strings.Repeat("b", 6),
+		"node_modules/x.js": "x\n",
+	})
+
+	tests := []struct {
+		mode Mode
+		want []Entry
+	}{
+		{
+			mode: ModeAuto,
+			want: []Entry{
+				{Path: "a.bin", Action: ActionCopied, Size: 6},
+				{Path: "b.bin", Action: ActionSkipped, Size: 6, Reason: "exceeds copy_max_size. Symlink it or raise the limit"},
+				{Path: "node_modules", Action: ActionSymlinked},
+			},
+		},
+		{
+			mode: ModeHardlink,
+			want: []Entry{
+				{Path: "a.bin", Action: ActionHardlinked, Size: 6},
+				{Path: "b.bin", Action: ActionHardlinked, Size: 6},
+				{Path: "node_modules", Action: ActionSymlinked},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			report, err := Apply(Options{
+				Source:  source,
+				Target:  target,
+				Copy:    []string{"*.bin"},
+				Symlink: []string{"node_modules"},
+				Mode:    tt.mode,
+				MaxSize: 10,
+				DryRun:  true,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(report.Entries, tt.want) {
+				t.Errorf("entries = %+v, want %+v", report.Entries, tt.want)
+			}
+			if entries, _ := os.ReadDir(target); len(entries) > 0 {
+				t.Errorf("the dry run wrote %d entries to the target", len(entries))
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+//go:build darwin
+
+package files
+
+import (
+	"errors"
+	"golang.org/x/sys/unix"
+	"io/fs"
+	"os"
+)
+
+// reflink clones src into dst with clonefile, which APFS supports
+func reflink(src, dst string, perm fs.FileMode) error {
+	if err := unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW); err != nil {
+		if errors.Is(err, unix.ENOTSUP) || errors.Is(err, unix.EXDEV) {
+			return ErrReflinkUnsupported
+		}
+		return err
+	}
+	return os.Chmod(dst, perm)
+}
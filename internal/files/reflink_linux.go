@@ -0,0 +1,34 @@
+//go:build linux
+
+package files
+
+import (
+	"errors"
+	"golang.org/x/sys/unix"
+	"io/fs"
+	"os"
+)
+
+// reflink clones src into dst with the FICLONE ioctl, which btrfs, XFS and other copy-on-write file systems support
+func reflink(src, dst string, perm fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlFileClone(int(out.Fd()), int(in.Fd())); err != nil {
+		out.Close()
+		os.Remove(dst)
+		if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOTTY) || errors.Is(err, unix.EXDEV) ||
+			errors.Is(err, unix.EINVAL) || errors.Is(err, unix.ENOSYS) {
+			return ErrReflinkUnsupported
+		}
+		return err
+	}
+	return out.Close()
+}
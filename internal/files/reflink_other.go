@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package files
+
+import "io/fs"
+
+func reflink(src, dst string, perm fs.FileMode) error {
+	return ErrReflinkUnsupported
+}
@@ -0,0 +1,329 @@
+package git
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	convertJournalName = "gwt-convert.json"
+	convertStagingName = ".gwt-convert"
+)
+
+// convertJournal records what ConvertRepo did so UndoConvert can revert it
+type convertJournal struct {
+	Branch          string   `json:"branch"`
+	WorktreePath    string   `json:"worktree_path"`
+	LinkedWorktrees []string `json:"linked_worktrees"`
+	TrackedConfig   []string `json:"tracked_config,omitempty"` // Config files copied into the worktree because they are tracked
+}
+
+// ConvertRepo converts a regular clone at dir into the bare layout in place. The .git directory becomes .bare, and the
+// files of the current branch, including uncommitted and untracked changes, move into the worktree at the path
+// worktree_path gives the branch. Linked worktrees inside the root and the config files stay in the root.
+func (g *Git) ConvertRepo(dir string) (string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	gitDir := filepath.Join(root, ".git")
+	if info, err := os.Stat(gitDir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("%s is not the root of a regular clone (no .git directory)", root)
+	}
+	if _, err := os.Stat(filepath.Join(root, bareDirName)); err == nil {
+		return "", fmt.Errorf("%s already exists", filepath.Join(root, bareDirName))
+	}
+	for _, marker := range []string{"MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD", "rebase-merge", "rebase-apply", "BISECT_LOG"} {
+		if _, err := os.Stat(filepath.Join(gitDir, marker)); err == nil {
+			return "", errors.New("a merge, rebase, cherry-pick, revert or bisect is in progress. Finish or abort it before converting")
+		}
+	}
+
+	output, err := exec.Command("git", "-C", root, "symbolic-ref", "--quiet", "--short", "HEAD").Output()
+	if err != nil {
+		return "", errors.New("HEAD is detached. Check out a branch before converting")
+	}
+	branch := strings.TrimSpace(string(output))
+
+	g.worktreeRoot = root
+	g.config = nil
+	worktreePath, err := g.WorktreePathFor(branch)
+	if err != nil {
+		return "", err
+	}
+	// Entries of the root are moved aside before the worktree is added, so only a path outside of it can collide
+	if !strings.HasPrefix(worktreePath, root+string(filepath.Separator)) {
+		if err := g.checkWorktreePath(worktreePath); err != nil {
+			return "", err
+		}
+	}
+	allWorktrees, err := g.listAllWorktrees()
+	if err != nil {
+		return "", err
+	}
+	journal := convertJournal{Branch: branch, WorktreePath: worktreePath}
+	for _, wt := range allWorktrees {
+		if !isSamePath(wt.Path, root) {
+			journal.LinkedWorktrees = append(journal.LinkedWorktrees, wt.Path)
+		}
+	}
+
+	// Linked worktrees inside the root stay where they are, and so do the config files, which the bare layout reads from
+	// the root. Tracked config files are copied into the worktree as well
+	skip := []string{".git", convertStagingName}
+	for _, path := range journal.LinkedWorktrees {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			skip = append(skip, strings.Split(rel, string(filepath.Separator))[0])
+		}
+	}
+	for _, name := range []string{_config.ConfigFileName, _config.LocalConfigFileName} {
+		skip = append(skip, name)
+		if _, err := os.Lstat(filepath.Join(root, name)); err == nil && g.IsTracked(name) {
+			journal.TrackedConfig = append(journal.TrackedConfig, name)
+		}
+	}
+
+	if err := writeConvertJournal(gitDir, journal); err != nil {
+		return "", err
+	}
+
+	undoHint := fmt.Errorf("conversion failed. Run 'gwt convert --undo %s' to restore the original clone", root)
+
+	// Move the working tree aside first so a branch named like an existing top-level entry does not collide
+	stagingDir := filepath.Join(root, convertStagingName)
+	if err := os.Mkdir(stagingDir, 0755); err != nil {
+		return "", err
+	}
+	if err := moveEntries(root, stagingDir, skip...); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+
+	bareDir := filepath.Join(root, bareDirName)
+	if err := os.Rename(gitDir, bareDir); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+	if err := os.WriteFile(gitDir, []byte("gitdir: ./"+bareDirName+"\n"), 0644); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+	if output, err := utils.Execute(exec.Command("git", "-C", root, "config", "core.bare", "true")); err != nil {
+		return "", errors.Join(errors.New(string(output)), undoHint)
+	}
+
+	output, err = utils.Execute(exec.Command("git", "-C", root, "worktree", "add", "--no-checkout", journal.WorktreePath, branch))
+	if err != nil {
+		return "", errors.Join(errors.New(string(output)), undoHint)
+	}
+
+	// Reuse the original index so staged changes survive
+	adminDir, err := worktreeAdminDir(journal.WorktreePath)
+	if err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+	if err := os.Rename(filepath.Join(bareDir, "index"), filepath.Join(adminDir, "index")); err != nil && !os.IsNotExist(err) {
+		return "", errors.Join(err, undoHint)
+	}
+
+	if err := moveEntries(stagingDir, journal.WorktreePath); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+	if err := os.Remove(stagingDir); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+	for _, name := range journal.TrackedConfig {
+		if err := copyFile(filepath.Join(root, name), filepath.Join(journal.WorktreePath, name)); err != nil {
+			return "", errors.Join(err, undoHint)
+		}
+	}
+
+	if err := repairWorktrees(root, journal.LinkedWorktrees); err != nil {
+		return "", errors.Join(err, undoHint)
+	}
+
+	return journal.WorktreePath, nil
+}
+
+// UndoConvert reverts ConvertRepo, including a conversion that stopped half way
+func (g *Git) UndoConvert(dir string) (string, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	gitDir := filepath.Join(root, ".git")
+	bareDir := filepath.Join(root, bareDirName)
+	journalDir := bareDir
+	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
+		journalDir = gitDir
+	}
+	journal, err := readConvertJournal(journalDir)
+	if err != nil {
+		return "", fmt.Errorf("no conversion to undo in %s: %w", root, err)
+	}
+
+	// Worktrees added after the conversion point at .bare as well and need repairing too
+	worktreesToRepair := journal.LinkedWorktrees
+	if journalDir == bareDir {
+		g.worktreeRoot = root
+		if worktrees, err := g.listAllWorktrees(); err == nil {
+			for _, wt := range worktrees {
+				if !wt.Bare && !isSamePath(wt.Path, journal.WorktreePath) && !slices.Contains(worktreesToRepair, wt.Path) {
+					worktreesToRepair = append(worktreesToRepair, wt.Path)
+				}
+			}
+		}
+	}
+
+	stagingDir := filepath.Join(root, convertStagingName)
+	if _, err := os.Stat(filepath.Join(journal.WorktreePath, ".git")); err == nil {
+		adminDir, err := worktreeAdminDir(journal.WorktreePath)
+		if err == nil {
+			if err := os.Rename(filepath.Join(adminDir, "index"), filepath.Join(journalDir, "index")); err != nil && !os.IsNotExist(err) {
+				return "", err
+			}
+			if err := os.RemoveAll(adminDir); err != nil {
+				return "", err
+			}
+		}
+		if err := os.Remove(filepath.Join(journal.WorktreePath, ".git")); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := os.Stat(stagingDir); err == nil {
+		if err := moveEntries(stagingDir, root); err != nil {
+			return "", err
+		}
+		if err := os.Remove(stagingDir); err != nil {
+			return "", err
+		}
+	}
+	if _, err := os.Stat(journal.WorktreePath); err == nil {
+		// The worktree's copy of a tracked config file replaces the one in the root, like before the conversion
+		for _, name := range journal.TrackedConfig {
+			if _, err := os.Lstat(filepath.Join(journal.WorktreePath, name)); err == nil {
+				if err := os.Remove(filepath.Join(root, name)); err != nil && !os.IsNotExist(err) {
+					return "", err
+				}
+			}
+		}
+		if err := moveEntries(journal.WorktreePath, root); err != nil {
+			return "", err
+		}
+		if err := os.Remove(journal.WorktreePath); err != nil {
+			return "", err
+		}
+		g.worktreeRoot = root
+		if err := g.removeEmptyParentDirs(journal.WorktreePath); err != nil {
+			return "", err
+		}
+	}
+
+	if journalDir == bareDir {
+		if err := os.Remove(gitDir); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		if err := os.Rename(bareDir, gitDir); err != nil {
+			return "", err
+		}
+	}
+	if output, err := utils.Execute(exec.Command("git", "-C", root, "config", "core.bare", "false")); err != nil {
+		return "", errors.New(string(output))
+	}
+	if err := repairWorktrees(root, worktreesToRepair); err != nil {
+		return "", err
+	}
+	if err := os.Remove(filepath.Join(gitDir, convertJournalName)); err != nil {
+		return "", err
+	}
+
+	return root, nil
+}
+
+// moveEntries renames every entry of src into dst, skipping the given names
+func moveEntries(src, dst string, skip ...string) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if slices.Contains(skip, entry.Name()) {
+			continue
+		}
+		target := filepath.Join(dst, entry.Name())
+		if _, err := os.Lstat(target); err == nil {
+			return fmt.Errorf("cannot move %s: %s already exists", entry.Name(), target)
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies the file at src to dst with the same permissions
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, content, info.Mode().Perm())
+}
+
+// worktreeAdminDir returns the directory in the common git directory that holds the worktree's HEAD and index
+func worktreeAdminDir(worktreePath string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(worktreePath, ".git"))
+	if err != nil {
+		return "", err
+	}
+	adminDir := strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	if !filepath.IsAbs(adminDir) {
+		adminDir = filepath.Join(worktreePath, adminDir)
+	}
+	return adminDir, nil
+}
+
+func repairWorktrees(root string, worktreePaths []string) error {
+	var existing []string
+	for _, path := range worktreePaths {
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	output, err := utils.Execute(exec.Command("git", append([]string{"-C", root, "worktree", "repair"}, existing...)...))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
+
+func writeConvertJournal(gitDir string, journal convertJournal) error {
+	content, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(gitDir, convertJournalName), content, 0644)
+}
+
+func readConvertJournal(gitDir string) (*convertJournal, error) {
+	content, err := os.ReadFile(filepath.Join(gitDir, convertJournalName))
+	if err != nil {
+		return nil, err
+	}
+	var journal convertJournal
+	if err := json.Unmarshal(content, &journal); err != nil {
+		return nil, err
+	}
+	return &journal, nil
+}
@@ -0,0 +1,64 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	g := newTestRepo(t)
+	root := g.worktreeRoot
+	commitFile(t, root, ".gwt.yml", "version: \"1.1\"\n", "add config")
+	runGit(t, root, "worktree", "add", "-q", "-b", "feature", filepath.Join(root, "wt", "feature"))
+	if err := os.WriteFile(filepath.Join(root, ".gwt.local.yml"), []byte("version: \"1.1\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "README"), []byte("staged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, root, "add", "README")
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("untracked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	status := runGit(t, root, "status", "--porcelain")
+
+	worktree, err := g.ConvertRepo(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if worktree != filepath.Join(root, "main") {
+		t.Fatalf("ConvertRepo() = %q, want %q", worktree, filepath.Join(root, "main"))
+	}
+	for _, path := range []string{".gwt.yml", ".gwt.local.yml", "wt/feature/README", "main/README", "main/notes.txt", "main/.gwt.yml"} {
+		if _, err := os.Lstat(filepath.Join(root, path)); err != nil {
+			t.Errorf("%s is missing after converting: %v", path, err)
+		}
+	}
+	for _, path := range []string{"README", "main/.gwt.local.yml", "main/wt"} {
+		if _, err := os.Lstat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after converting", path)
+		}
+	}
+	if got, want := runGit(t, worktree, "status", "--porcelain"), "M  README\n?? notes.txt"; got != want {
+		t.Errorf("status of the worktree = %q, want %q", got, want)
+	}
+	if got := runGit(t, filepath.Join(root, "wt", "feature"), "rev-parse", "--abbrev-ref", "HEAD"); got != "feature" {
+		t.Errorf("linked worktree is on %q, want feature", got)
+	}
+
+	if _, err := g.UndoConvert(root); err != nil {
+		t.Fatal(err)
+	}
+	if got := runGit(t, root, "status", "--porcelain"); got != status {
+		t.Errorf("status after undoing = %q, want %q", got, status)
+	}
+	for _, path := range []string{"main", bareDirName} {
+		if _, err := os.Lstat(filepath.Join(root, path)); !os.IsNotExist(err) {
+			t.Errorf("%s exists after undoing", path)
+		}
+	}
+	if got := runGit(t, filepath.Join(root, "wt", "feature"), "rev-parse", "--abbrev-ref", "HEAD"); got != "feature" {
+		t.Errorf("linked worktree is on %q after undoing, want feature", got)
+	}
+}
@@ -4,16 +4,17 @@ import (
 	"errors"
 	"fmt"
 	"github.com/charmbracelet/huh/spinner"
-	"github.com/jcelaya775/gwt/internal/config"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/utils"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
 type Git struct {
 	worktreeRoot string
+	config       *_config.Config // Configuration used to resolve worktree paths, loaded on first use
 }
 
 func New() (*Git, error) {
@@ -22,6 +23,9 @@ func New() (*Git, error) {
 
 func (g *Git) SetWorktreeRoot() error {
 	worktreeRoot, err := getWorktreeRoot()
+	if worktreeRoot != g.worktreeRoot {
+		g.config = nil
+	}
 	g.worktreeRoot = worktreeRoot
 	return err
 }
@@ -30,182 +34,425 @@ func (g *Git) GetWorktreeRoot() string {
 	return g.worktreeRoot
 }
 
+// GetCurrentWorktreePath returns the top level directory of the worktree containing the working directory
+func (*Git) GetCurrentWorktreePath() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").CombinedOutput()
+	if err != nil {
+		return "", errors.New(string(output))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
 func (g *Git) GetRepoName() string {
 	return filepath.Base(g.worktreeRoot)
 }
 
-func (g *Git) CloneRepo(repoURL string, dir string) error {
-	var cmdArgs []string
-	if dir != "" {
-		cmdArgs = []string{"clone", "--no-checkout", repoURL, dir}
-	} else {
-		cmdArgs = []string{"clone", "--no-checkout", repoURL}
-	}
-	output, err := exec.Command("git", cmdArgs...).CombinedOutput()
-	fmt.Println(string(output))
-	if err != nil {
-		return errors.New(string(output))
+// Layout is the directory structure gwt clone creates for a repository
+type Layout string
+
+const (
+	// LayoutBare keeps the repository in a .bare directory next to a .git pointer file, with every branch in a worktree
+	LayoutBare Layout = "bare"
+	// LayoutDummy checks out a placeholder branch in the repository root and adds worktrees inside it
+	LayoutDummy Layout = "dummy"
+
+	bareDirName = ".bare"
+)
+
+var Layouts = []string{string(LayoutBare), string(LayoutDummy)}
+
+func ParseLayout(layout string) (Layout, error) {
+	switch Layout(layout) {
+	case LayoutBare, LayoutDummy:
+		return Layout(layout), nil
+	default:
+		return "", fmt.Errorf("unknown layout '%s'. Supported layouts: %s", layout, strings.Join(Layouts, ", "))
 	}
+}
 
+// CloneRepo clones the repository in the layout and returns the default branch and the path of its worktree
+func (g *Git) CloneRepo(repoURL string, dir string, layout Layout) (string, string, error) {
 	var repoDir string
 	if dir != "" {
 		repoDir = dir
 	} else {
-		parts := strings.Split(repoURL, "/")
+		parts := strings.Split(strings.TrimSuffix(repoURL, "/"), "/")
 		repoDir = strings.TrimSuffix(parts[len(parts)-1], ".git")
 	}
 	repoPath, err := filepath.Abs(repoDir)
 	if err != nil {
-		return err
+		return "", "", err
+	}
+
+	switch layout {
+	case LayoutDummy:
+		return g.cloneDummy(repoURL, repoPath)
+	default:
+		return g.cloneBare(repoURL, repoPath)
+	}
+}
+
+// cloneBare clones the repository into <dir>/.bare, points <dir>/.git at it and adds a worktree for the default branch
+func (g *Git) cloneBare(repoURL string, repoPath string) (string, string, error) {
+	bareDir := filepath.Join(repoPath, bareDirName)
+	output, err := utils.Execute(exec.Command("git", "clone", "--bare", repoURL, bareDir))
+	if len(output) > 0 {
+		fmt.Println(string(output))
+	}
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+	if !utils.DryRun() {
+		fmt.Println("Repository cloned to:", repoPath)
 	}
-	fmt.Println("Repository cloned to:", repoPath)
 	g.worktreeRoot = repoPath
+	g.config = nil
+
+	gitFile := filepath.Join(repoPath, ".git")
+	if utils.DryRun() {
+		utils.RecordDryRun("write %s pointing at ./%s", gitFile, bareDirName)
+	} else if err := os.WriteFile(gitFile, []byte("gitdir: ./"+bareDirName+"\n"), 0644); err != nil {
+		return "", "", err
+	}
+
+	// Bare clones do not track remote branches by default
+	output, err = utils.Execute(exec.Command("git", "-C", repoPath, "config", "remote.origin.fetch", "+refs/heads/*:refs/remotes/origin/*"))
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+	output, err = utils.Execute(exec.Command("git", "-C", repoPath, "fetch", "origin"))
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+
+	defaultBranch, err := g.clonedDefaultBranch(repoURL, repoPath, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+
+	// Bare clones copy every remote branch as a local branch. Keep only the default branch so the others are listed
+	// as remote branches and get their upstream configured when a worktree is added for them.
+	branches, err := g.clonedBranches(repoURL, repoPath)
+	if err != nil {
+		return "", "", err
+	}
+	for _, branch := range branches {
+		if branch == defaultBranch {
+			continue
+		}
+		if output, err := utils.Execute(exec.Command("git", "-C", repoPath, "branch", "-D", branch)); err != nil {
+			return "", "", errors.New(string(output))
+		}
+	}
+
+	worktreePath, err := g.WorktreePathFor(defaultBranch)
+	if err != nil {
+		return "", "", err
+	}
+	fmt.Println("Creating worktree for default branch:", defaultBranch)
+	output, err = utils.Execute(exec.Command("git", "-C", repoPath, "worktree", "add", worktreePath, defaultBranch))
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+	output, err = utils.Execute(exec.Command("git", "-C", repoPath, "branch", "--set-upstream-to", "origin/"+defaultBranch, defaultBranch))
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+
+	return defaultBranch, worktreePath, nil
+}
+
+// clonedDefaultBranch reads the default branch of the clone at repoPath with the git command. In a dry run nothing is
+// cloned, so the remote is asked which branch its HEAD points at instead.
+func (*Git) clonedDefaultBranch(repoURL string, repoPath string, args ...string) (string, error) {
+	if !utils.DryRun() {
+		output, err := exec.Command("git", append([]string{"-C", repoPath}, args...)...).CombinedOutput()
+		if err != nil {
+			return "", errors.New(string(output))
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	output, err := exec.Command("git", "ls-remote", "--symref", repoURL, "HEAD").CombinedOutput()
+	if err != nil {
+		return "", errors.New(string(output))
+	}
+	// The first line reads "ref: refs/heads/<branch>\tHEAD"
+	for _, line := range strings.Split(string(output), "\n") {
+		if ref, ok := strings.CutPrefix(line, "ref: "); ok {
+			ref, _, _ = strings.Cut(ref, "\t")
+			return strings.TrimPrefix(ref, "refs/heads/"), nil
+		}
+	}
+	return "", fmt.Errorf("could not find the default branch of %s", repoURL)
+}
+
+// clonedBranches lists the local branches of the bare clone at repoPath. In a dry run nothing is cloned, so the
+// branches of the remote, which the clone would copy, are listed instead.
+func (*Git) clonedBranches(repoURL string, repoPath string) ([]string, error) {
+	if !utils.DryRun() {
+		output, err := exec.Command("git", "-C", repoPath, "for-each-ref", "--format=%(refname:short)", "refs/heads").CombinedOutput()
+		if err != nil {
+			return nil, errors.New(string(output))
+		}
+		return strings.Fields(string(output)), nil
+	}
+
+	output, err := exec.Command("git", "ls-remote", "--heads", repoURL).CombinedOutput()
+	if err != nil {
+		return nil, errors.New(string(output))
+	}
+	// Every line reads "<sha>\trefs/heads/<branch>"
+	var branches []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if _, ref, ok := strings.Cut(line, "\t"); ok {
+			branches = append(branches, strings.TrimPrefix(ref, "refs/heads/"))
+		}
+	}
+	return branches, nil
+}
+
+// cloneDummy clones the repository, checks out a placeholder branch in the root and adds a worktree for the default branch
+func (g *Git) cloneDummy(repoURL string, repoPath string) (string, string, error) {
+	output, err := utils.Execute(exec.Command("git", "clone", "--no-checkout", repoURL, repoPath))
+	if len(output) > 0 {
+		fmt.Println(string(output))
+	}
+	if err != nil {
+		return "", "", errors.New(string(output))
+	}
+	if !utils.DryRun() {
+		fmt.Println("Repository cloned to:", repoPath)
+	}
+	g.worktreeRoot = repoPath
+	g.config = nil
 
 	// TODO: Checkout a dummy branch and create worktree for main branch relative to worktree dir (config)
 	// TODO: Create pseudo-random branch name to avoid conflicts, random 6 digit number
 
-	output, err = exec.Command("git", "-C", g.worktreeRoot, "branch", "--show-current").CombinedOutput()
+	originalBranch, err := g.clonedDefaultBranch(repoURL, g.worktreeRoot, "branch", "--show-current")
 	if err != nil {
-		return errors.New(string(output))
+		return "", "", err
 	}
-	originalBranch := strings.TrimSpace(string(output))
 	fmt.Println("Original branch:", originalBranch)
 
 	dummyBranch := "dummy"
 	fmt.Println("Creating dummy branch:", dummyBranch)
-	output, err = exec.Command("git", "-C", g.worktreeRoot, "checkout", "-b", dummyBranch).CombinedOutput()
+	output, err = utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "checkout", "-b", dummyBranch))
 	if err != nil {
-		return errors.New(string(output))
+		return "", "", errors.New(string(output))
 	}
 
-	var worktreeArgs []string
-	worktreeArgs = []string{"-C", g.worktreeRoot, "worktree", "add", originalBranch, originalBranch}
+	worktreePath, err := g.WorktreePathFor(originalBranch)
+	if err != nil {
+		return "", "", err
+	}
 	fmt.Println("Creating worktree for original branch:", originalBranch)
-	output, err = exec.Command("git", worktreeArgs...).CombinedOutput()
+	output, err = utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "worktree", "add", worktreePath, originalBranch))
 	if err != nil {
-		return errors.New(string(output))
+		return "", "", errors.New(string(output))
 	}
 
-	return nil
+	return originalBranch, worktreePath, nil
 }
 
-func (g *Git) AddWorktree(config *config.Config, branch string, commitish string, noPull bool, force bool) (string, error) {
-	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "add"}
-
-	var baseBranch string
+// WorktreePlan is what adding a worktree for a branch involves, worked out before anything is changed
+type WorktreePlan struct {
+	Branch       string // Branch checked out in the worktree, without an origin/ prefix
+	Path         string // Absolute path of the worktree
+	BaseBranch   string // Branch or commit the worktree's branch starts from, pulled before the worktree is added
+	NewBranch    bool   // Whether the branch is created from BaseBranch
+	ParentBranch string // The commit-ish given, or base_branch with @latest-tag resolved, also for existing branches
+
+	baseWorktree   *Worktree
+	existsLocally  bool
+	existsRemotely bool
+}
 
+// PlanWorktree works out the path, base branch and whether a new branch is needed to add a worktree for the branch.
+// A commit-ish creates a new branch from it, and a branch that only exists remotely is created from origin.
+func (g *Git) PlanWorktree(config *_config.Config, branch string, commitish string) (*WorktreePlan, error) {
 	existsLocally, err := g.BranchExistsLocally(branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	existsRemotely, err := g.BranchExistsRemotely(branch)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !existsRemotely && strings.HasPrefix(branch, "origin/") {
-		return "", fmt.Errorf("branch '%s' does not exist remotely. Remove the 'origin/' prefix to create a new branch", branch)
+		return nil, fmt.Errorf("branch '%s' does not exist remotely. Remove the 'origin/' prefix to create a new branch", branch)
 	}
 
 	parsedBranch := strings.TrimPrefix(branch, "origin/")
+	g.config = config
+	branchConfig := config.ForBranch(parsedBranch)
+	worktreePath, err := branchConfig.ResolveWorktreePath(g.worktreeRoot)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.checkWorktreePath(worktreePath); err != nil {
+		return nil, err
+	}
+
+	plan := &WorktreePlan{Branch: parsedBranch, Path: worktreePath, NewBranch: true, ParentBranch: commitish, existsLocally: existsLocally, existsRemotely: existsRemotely}
+	if plan.ParentBranch == "" {
+		if plan.ParentBranch, err = g.ResolveBaseBranch(config, branchConfig.BaseBranch); err != nil {
+			return nil, err
+		}
+	}
 	if commitish != "" {
-		baseBranch = commitish
-		cmdArgs = append(cmdArgs, "-b", parsedBranch, parsedBranch, "--checkout", commitish)
+		plan.BaseBranch = commitish
 	} else if existsLocally {
-		baseBranch = parsedBranch
-		cmdArgs = append(cmdArgs, parsedBranch, "--checkout", baseBranch)
+		plan.BaseBranch = parsedBranch
+		plan.NewBranch = false
 	} else if existsRemotely {
-		baseBranch = branch
-		cmdArgs = append(cmdArgs, "-b", parsedBranch, parsedBranch, "--checkout", branch)
+		plan.BaseBranch = "origin/" + parsedBranch
 	} else {
-		baseBranch = config.Defaults.BaseBranch
-		cmdArgs = append(cmdArgs, "-b", parsedBranch, parsedBranch, baseBranch)
-	}
-
-	worktreeExists, err := g.WorktreeExists(baseBranch)
-
-	if !noPull && !strings.HasPrefix(baseBranch, "origin/") {
-		// TODO: Check for uncommitted changes or merge conflicts, and prompt user with confirmation message before pulling
-		var err error
-		baseBranchPath := filepath.Join(g.worktreeRoot, baseBranch)
-		_ = spinner.New().
-			Title(fmt.Sprintf("Pulling base branch '%s'... (press ctrl-c to skip)", baseBranch)).
-			Action(func() {
-				var output []byte
-				var innerErr error
-				if worktreeExists {
-					output, innerErr = exec.Command("git", "-C", baseBranchPath, "pull").CombinedOutput()
-				} else if existsLocally && existsRemotely {
-					output, innerErr = exec.Command("git", "-C", g.worktreeRoot, "fetch", "origin", fmt.Sprintf("%s:%s", baseBranch, baseBranch)).CombinedOutput()
-				}
-
-				if innerErr != nil {
-					err = errors.New(string(output))
-				}
-			}).
-			Run()
-		if err != nil {
-			return "", errors.Join(err, fmt.Errorf("failed to pull base branch. You can retry without pulling using the --no-pull flag"))
-		}
+		plan.BaseBranch = plan.ParentBranch
+	}
+
+	if plan.baseWorktree, err = g.GetWorktree(plan.BaseBranch); err != nil && !errors.Is(err, ErrWorktreeNotFound) {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// PullBaseBranch brings the plan's base branch up to date with origin, pulling in its worktree if it has one. Remote
+// branches are already up to date after a fetch.
+func (g *Git) PullBaseBranch(plan *WorktreePlan) error {
+	if strings.HasPrefix(plan.BaseBranch, "origin/") {
+		return nil
+	}
+	var pullCmd *exec.Cmd
+	if plan.baseWorktree != nil {
+		pullCmd = exec.Command("git", "-C", plan.baseWorktree.Path, "pull")
+	} else if plan.existsLocally && plan.existsRemotely {
+		pullCmd = exec.Command("git", "-C", g.worktreeRoot, "fetch", "origin", fmt.Sprintf("%s:%s", plan.BaseBranch, plan.BaseBranch))
+	} else {
+		return nil
+	}
+	if utils.DryRun() {
+		_, _ = utils.Execute(pullCmd)
+		return nil
+	}
+
+	// TODO: Check for uncommitted changes or merge conflicts, and prompt user with confirmation message before pulling
+	var err error
+	_ = spinner.New().
+		Title(fmt.Sprintf("Pulling base branch '%s'... (press ctrl-c to skip)", plan.BaseBranch)).
+		Action(func() {
+			if output, innerErr := utils.Execute(pullCmd); innerErr != nil {
+				err = errors.New(string(output))
+			}
+		}).
+		Run()
+	if err != nil {
+		return errors.Join(err, fmt.Errorf("failed to pull base branch. You can retry without pulling using the --no-pull flag"))
 	}
+	return nil
+}
 
+// CreateBranch creates the plan's new branch from its base branch. A remote base branch becomes its upstream.
+func (g *Git) CreateBranch(plan *WorktreePlan) error {
+	output, err := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "branch", plan.Branch, plan.BaseBranch))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
+
+// DeleteBranch force deletes a local branch
+func (g *Git) DeleteBranch(branch string) error {
+	output, err := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "branch", "-D", branch))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
+
+// CreateWorktree adds the plan's worktree with its branch checked out, which must exist by then. Force checks the
+// branch out even if another worktree has it checked out.
+func (g *Git) CreateWorktree(plan *WorktreePlan, force bool) error {
+	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "add", plan.Path, plan.Branch}
 	if force {
 		cmdArgs = append(cmdArgs, "--force")
 	}
 
-	output, err := exec.Command("git", cmdArgs...).CombinedOutput()
+	output, err := utils.Execute(exec.Command("git", cmdArgs...))
 	if err != nil {
-		return "", errors.New(string(output))
+		return errors.New(string(output))
+	}
+	if len(output) > 0 {
+		fmt.Println(string(output))
 	}
-	fmt.Println(string(output))
+	return nil
+}
 
-	worktreePathRegex, err := regexp.Compile("Preparing worktree.*'(.*)'")
-	if err != nil {
-		return "", err
+// ResolveBaseBranch returns the commit-ish a base branch setting refers to, resolving @latest-tag
+func (g *Git) ResolveBaseBranch(config *_config.Config, baseBranch string) (string, error) {
+	if baseBranch != _config.LatestTagBaseBranch {
+		return baseBranch, nil
 	}
-	matches := worktreePathRegex.FindStringSubmatch(string(output))
-	if len(matches) < 2 {
-		return "", errors.New("could not parse worktree path from git output")
+	return g.latestTag(config.Defaults.BaseBranch)
+}
+
+// latestTag returns the most recent tag reachable from branch
+func (g *Git) latestTag(branch string) (string, error) {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "describe", "--tags", "--abbrev=0", branch).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the latest tag on '%s': %s", branch, strings.TrimSpace(string(output)))
 	}
-	worktreePath := filepath.Join(g.worktreeRoot, matches[1])
-	return worktreePath, nil
+	return strings.TrimSpace(string(output)), nil
 }
 
-func (g *Git) RemoveWorktree(worktree string, force, keepBranch bool) error {
-	branch, err := g.GetWorktreeBranch(worktree)
+// RemoveWorktree removes the worktree and, unless keepBranch is set, its branch. A force count of one removes worktrees
+// with uncommitted changes and a count of two also removes locked worktrees, mirroring `git worktree remove -f -f`.
+func (g *Git) RemoveWorktree(worktree string, force int, keepBranch bool) error {
+	wt, err := g.GetWorktree(worktree)
+	if err != nil {
+		return err
+	}
+
+	if wt.Locked && force < 2 {
+		return &LockedError{Worktree: wt.Name, Reason: wt.LockedReason}
+	}
 
 	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "remove"}
-	if force {
+	for range min(force, 2) {
 		cmdArgs = append(cmdArgs, "--force")
 	}
-	cmdArgs = append(cmdArgs, worktree)
+	cmdArgs = append(cmdArgs, wt.Path)
 
-	output, err := exec.Command("git", cmdArgs...).CombinedOutput()
+	output, err := utils.Execute(exec.Command("git", cmdArgs...))
 	if err != nil {
 		return errors.New(string(output))
 	}
 
-	if !keepBranch {
-		if err != nil {
-			return err
-		}
-		branchOutput, branchErr := exec.Command("git", "-C", g.worktreeRoot, "branch", "-D", branch).CombinedOutput()
+	if !keepBranch && wt.Branch != "" {
+		branchOutput, branchErr := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "branch", "-D", wt.BranchName()))
 		if branchErr != nil {
 			return errors.New(string(branchOutput))
 		}
 	}
 
-	if err = g.removeEmptyParentDirs(worktree); err != nil {
+	if utils.DryRun() {
+		return nil
+	}
+	if err = g.removeEmptyParentDirs(wt.Path); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (g *Git) removeEmptyParentDirs(worktree string) error {
-	worktreePath := filepath.Join(g.worktreeRoot, worktree)
+// removeEmptyParentDirs removes the directories left empty by removing a worktree, up to the repository root or, for
+// worktrees outside of it, the directory containing the root
+func (g *Git) removeEmptyParentDirs(worktreePath string) error {
+	rootParent := filepath.Dir(filepath.Clean(g.worktreeRoot))
 	parentDir := filepath.Dir(worktreePath)
-	for parentDir != g.worktreeRoot {
+	for !isSamePath(parentDir, g.worktreeRoot) && !isSamePath(parentDir, rootParent) && strings.HasPrefix(parentDir, rootParent) {
 		dirEntries, err := os.ReadDir(parentDir)
 		if err != nil {
 			return err
@@ -222,38 +469,72 @@ func (g *Git) removeEmptyParentDirs(worktree string) error {
 	return nil
 }
 
-func (g *Git) ListWorktrees() ([]string, error) {
-	output, err := exec.Command("git", "worktree", "list").Output()
+// ListWorktrees returns the linked worktrees of the repository, excluding the worktree root itself
+func (g *Git) ListWorktrees() ([]Worktree, error) {
+	allWorktrees, err := g.listAllWorktrees()
 	if err != nil {
-		return nil, errors.New(string(output))
+		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	worktrees := make([]string, 0, len(lines))
-	for _, line := range lines[1:] {
-		worktreeAbsPath := strings.Fields(line)[0]
-		worktreeRelPath := strings.TrimPrefix(worktreeAbsPath, g.worktreeRoot)
-		worktrees = append(worktrees, worktreeRelPath)
+	worktrees := make([]Worktree, 0, len(allWorktrees))
+	for _, wt := range allWorktrees {
+		if wt.Bare || isSamePath(wt.Path, g.worktreeRoot) {
+			continue
+		}
+		// Worktrees at the path worktree_path gives their branch are named after the branch
+		if wt.Branch != "" {
+			if path, err := g.WorktreePathFor(wt.BranchName()); err == nil && isSamePath(path, wt.Path) {
+				wt.Name = wt.BranchName()
+			}
+		}
+		worktrees = append(worktrees, wt)
 	}
 	return worktrees, nil
 }
 
+var ErrWorktreeNotFound = errors.New("worktree not found")
+
+// GetWorktree returns the worktree with the given name. Names are resolved through worktree_path, so a branch name
+// finds the worktree at the branch's path, and also match worktrees by branch or path relative to the root.
+func (g *Git) GetWorktree(worktree string) (*Worktree, error) {
+	worktrees, err := g.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.Trim(filepath.ToSlash(worktree), "/")
+	for _, wt := range worktrees {
+		if wt.Name == name {
+			return &wt, nil
+		}
+	}
+	if path, err := g.WorktreePathFor(name); err == nil {
+		for _, wt := range worktrees {
+			if isSamePath(wt.Path, path) {
+				return &wt, nil
+			}
+		}
+	}
+	for _, wt := range worktrees {
+		if (wt.Branch != "" && wt.BranchName() == name) || relativeName(g.worktreeRoot, wt.Path) == name {
+			return &wt, nil
+		}
+	}
+	return nil, ErrWorktreeNotFound
+}
+
 func (g *Git) ListBranches(onlyLocal, hideBranchesWithWorktrees bool) ([]string, error) {
 	worktreeBranches := make(map[string]bool)
 	if hideBranchesWithWorktrees {
-		output, err := exec.Command("git", "worktree", "list").Output()
+		worktrees, err := g.listAllWorktrees()
 		if err != nil {
-			return nil, errors.New(string(output))
+			return nil, err
 		}
 
-		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, line := range lines {
-			parts := strings.Fields(line)
-			if len(parts) < 3 {
-				return nil, errors.New("could not parse worktree list output")
+		for _, wt := range worktrees {
+			if wt.Branch != "" {
+				worktreeBranches[wt.BranchName()] = true
 			}
-			worktreeBranch := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
-			worktreeBranches[worktreeBranch] = true
 		}
 	}
 
@@ -303,36 +584,34 @@ func (g *Git) ListBranches(onlyLocal, hideBranchesWithWorktrees bool) ([]string,
 	return branches, nil
 }
 
+// GetWorktreeBranch returns the short branch name checked out in the worktree, or an empty string if HEAD is detached
 func (g *Git) GetWorktreeBranch(worktree string) (string, error) {
-	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "list"}
-	output, err := exec.Command("git", cmdArgs...).Output()
+	wt, err := g.GetWorktree(worktree)
 	if err != nil {
-		return "", errors.New(string(output))
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		worktreeAbsPath := fields[0]
-		worktreeRelPath := strings.TrimPrefix(worktreeAbsPath, g.worktreeRoot)
-		if worktreeRelPath == worktree {
-			if len(fields) < 3 {
-				return "", errors.New("could not determine branch for worktree")
-			}
-			unparsedBranch := fields[2]
-			return strings.TrimSuffix(strings.TrimPrefix(unparsedBranch, "["), "]"), nil
-		}
+		return "", err
 	}
-	return "", errors.New("worktree not found")
+	return wt.BranchName(), nil
 }
 
 func (*Git) Fetch() error {
+	return fetch("fetch")
+}
+
+// FetchPrune fetches and removes remote-tracking branches that no longer exist on the remote
+func (*Git) FetchPrune() error {
+	return fetch("fetch", "--prune")
+}
+
+func fetch(args ...string) error {
+	if utils.DryRun() {
+		_, err := utils.Execute(exec.Command("git", args...))
+		return err
+	}
 	var err error
 	_ = spinner.New().
 		Title("Syncing with remote... (press ctrl-c to skip)").
 		Action(func() {
-			cmd := exec.Command("git", "fetch")
-			output, innerErr := cmd.CombinedOutput()
+			output, innerErr := utils.Execute(exec.Command("git", args...))
 			if innerErr != nil {
 				err = errors.New(string(output))
 			}
@@ -342,17 +621,14 @@ func (*Git) Fetch() error {
 }
 
 func (g *Git) WorktreeExists(worktree string) (bool, error) {
-	worktreeBranch, err := g.GetWorktreeBranch(worktree)
+	_, err := g.GetWorktree(worktree)
 	if err != nil {
-		if strings.Contains(err.Error(), "worktree not found") {
+		if errors.Is(err, ErrWorktreeNotFound) {
 			return false, nil
 		}
 		return false, err
 	}
-	if worktreeBranch != "" {
-		return true, nil
-	}
-	return false, nil
+	return true, nil
 }
 
 func (*Git) BranchExistsLocally(branch string) (bool, error) {
@@ -382,6 +658,14 @@ func (*Git) BranchExistsRemotely(branch string) (bool, error) {
 	return false, nil
 }
 
+// CheckBranchName returns an error if git does not accept the name as a branch name, e.g. feature//x
+func (*Git) CheckBranchName(branch string) error {
+	if err := exec.Command("git", "check-ref-format", "refs/heads/"+branch).Run(); err != nil {
+		return fmt.Errorf("'%s' is not a valid branch name", branch)
+	}
+	return nil
+}
+
 func getWorktreeRoot() (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--git-common-dir")
 	output, err := cmd.Output()
@@ -390,13 +674,49 @@ func getWorktreeRoot() (string, error) {
 	}
 
 	gitDir := strings.TrimSpace(string(output))
-	if strings.HasSuffix(gitDir, ".git") {
+	if strings.HasSuffix(gitDir, ".git") || filepath.Base(gitDir) == bareDirName {
 		gitDirAbsolute, err := filepath.Abs(gitDir)
 		if err != nil {
 			return "", err
 		}
+		if resolved, err := filepath.EvalSymlinks(gitDirAbsolute); err == nil {
+			gitDirAbsolute = resolved
+		}
 		return fmt.Sprintf("%s/", filepath.Dir(gitDirAbsolute)), nil
 	} else {
 		return "", errors.New("could not find git repository root containing .git. Please use gwt clone to clone the repository")
 	}
 }
+
+// IsTracked reports whether the file at path, relative to the repository root, is committed to the repository
+func (g *Git) IsTracked(path string) bool {
+	return exec.Command("git", "-C", g.worktreeRoot, "ls-files", "--error-unmatch", "--", path).Run() == nil
+}
+
+// AddExclude adds pattern to the repository's info/exclude file unless it is already listed there
+func (g *Git) AddExclude(pattern string) error {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "rev-parse", "--path-format=absolute", "--git-common-dir").CombinedOutput()
+	if err != nil {
+		return errors.New(string(output))
+	}
+	excludePath := filepath.Join(strings.TrimSpace(string(output)), "info", "exclude")
+
+	content, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		content = append(content, '\n')
+	}
+	content = append(content, pattern+"\n"...)
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(excludePath, content, 0644)
+}
@@ -0,0 +1,33 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsTracked(t *testing.T) {
+	g := newTestRepo(t)
+	root := g.worktreeRoot
+	commitFile(t, root, ".gwt.local.yml", "init_commands: [make]\n", "track the local config")
+	if err := os.WriteFile(filepath.Join(root, "untracked.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: ".gwt.local.yml", want: true},
+		{path: "README", want: true},
+		{path: "untracked.yml"},
+		{path: "missing.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := g.IsTracked(tt.path); got != tt.want {
+				t.Errorf("IsTracked(%s) = %t, want %t", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"os/exec"
+)
+
+// LockedError is returned when an operation refuses to touch a locked worktree
+type LockedError struct {
+	Worktree string
+	Reason   string
+}
+
+func (e *LockedError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("worktree '%s' is locked: %s", e.Worktree, e.Reason)
+	}
+	return fmt.Sprintf("worktree '%s' is locked", e.Worktree)
+}
+
+// LockWorktree locks the worktree so it cannot be moved, pruned or removed without forcing twice
+func (g *Git) LockWorktree(worktree string, reason string) error {
+	wt, err := g.GetWorktree(worktree)
+	if err != nil {
+		return err
+	}
+	if wt.Locked {
+		return fmt.Errorf("worktree '%s' is already locked", wt.Name)
+	}
+
+	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "lock"}
+	if reason != "" {
+		cmdArgs = append(cmdArgs, "--reason", reason)
+	}
+	cmdArgs = append(cmdArgs, wt.Path)
+
+	output, err := utils.Execute(exec.Command("git", cmdArgs...))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
+
+// UnlockWorktree unlocks a locked worktree
+func (g *Git) UnlockWorktree(worktree string) error {
+	wt, err := g.GetWorktree(worktree)
+	if err != nil {
+		return err
+	}
+	if !wt.Locked {
+		return fmt.Errorf("worktree '%s' is not locked", wt.Name)
+	}
+
+	output, err := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "worktree", "unlock", wt.Path))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// MoveWorktree renames the worktree's branch to newBranch and moves the worktree to the matching path. It returns the
+// moved worktree with its old path, so callers can update anything that refers to it.
+func (g *Git) MoveWorktree(worktree string, newBranch string, force int) (*Worktree, string, error) {
+	wt, err := g.GetWorktree(worktree)
+	if err != nil {
+		return nil, "", err
+	}
+	if wt.Branch == "" {
+		return nil, "", fmt.Errorf("worktree '%s' has no branch to rename", wt.Name)
+	}
+	if wt.Locked && force < 2 {
+		return nil, "", &LockedError{Worktree: wt.Name, Reason: wt.LockedReason}
+	}
+
+	oldBranch := wt.BranchName()
+	newPath, err := g.WorktreePathFor(newBranch)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := g.checkWorktreePath(newPath); err != nil {
+		return nil, "", err
+	}
+
+	output, err := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "branch", "-m", oldBranch, newBranch))
+	if err != nil {
+		return nil, "", errors.New(string(output))
+	}
+
+	if moveErr := g.moveWorktreeDir(wt.Path, newPath, force); moveErr != nil {
+		if revertOutput, revertErr := utils.Execute(exec.Command("git", "-C", g.worktreeRoot, "branch", "-m", newBranch, oldBranch)); revertErr != nil {
+			return nil, "", errors.Join(moveErr, errors.New(string(revertOutput)))
+		}
+		return nil, "", moveErr
+	}
+
+	oldPath := wt.Path
+	moved, err := g.GetWorktree(newBranch)
+	if err != nil {
+		return nil, "", err
+	}
+	return moved, oldPath, nil
+}
+
+// RelayoutWorktree moves the worktree to the path worktree_path gives its branch. It returns the new path, which equals
+// the old one when the worktree is already in place.
+func (g *Git) RelayoutWorktree(wt Worktree, force int) (string, error) {
+	if wt.Branch == "" {
+		return wt.Path, nil
+	}
+	newPath, err := g.WorktreePathFor(wt.BranchName())
+	if err != nil {
+		return "", err
+	}
+	if isSamePath(newPath, wt.Path) {
+		return wt.Path, nil
+	}
+	if wt.Locked && force < 2 {
+		return "", &LockedError{Worktree: wt.Name, Reason: wt.LockedReason}
+	}
+	if err := g.checkWorktreePath(newPath); err != nil {
+		return "", err
+	}
+	if err := g.moveWorktreeDir(wt.Path, newPath, force); err != nil {
+		return "", err
+	}
+	return newPath, nil
+}
+
+// moveWorktreeDir moves a worktree with `git worktree move`, creating the new parent directories and removing the old
+// ones left empty
+func (g *Git) moveWorktreeDir(oldPath, newPath string, force int) error {
+	if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+		return err
+	}
+	cmdArgs := []string{"-C", g.worktreeRoot, "worktree", "move"}
+	for range min(force, 2) {
+		cmdArgs = append(cmdArgs, "--force")
+	}
+	cmdArgs = append(cmdArgs, oldPath, newPath)
+	output, err := utils.Execute(exec.Command("git", cmdArgs...))
+	if err != nil {
+		_ = g.removeEmptyParentDirs(newPath)
+		return errors.New(string(output))
+	}
+	return g.removeEmptyParentDirs(oldPath)
+}
+
+// RenameRemoteBranch pushes newBranch to the remote of oldBranch's upstream, makes it the new upstream and deletes
+// the old remote branch. It does nothing when the branch has no upstream.
+func (g *Git) RenameRemoteBranch(worktreePath string, oldUpstream string, newBranch string) error {
+	remote, oldRemoteBranch, ok := strings.Cut(oldUpstream, "/")
+	if !ok {
+		return nil
+	}
+
+	output, err := utils.Execute(exec.Command("git", "-C", worktreePath, "push", "--set-upstream", remote, newBranch))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	output, err = utils.Execute(exec.Command("git", "-C", worktreePath, "push", remote, "--delete", oldRemoteBranch))
+	if err != nil {
+		return errors.New(string(output))
+	}
+	return nil
+}
+
+// GetUpstream returns the upstream of the branch, e.g. origin/main, or an empty string if it has none
+func (g *Git) GetUpstream(branch string) string {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "rev-parse", "--abbrev-ref", branch+"@{upstream}").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
@@ -0,0 +1,209 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type PruneReason string
+
+const (
+	PruneMerged       PruneReason = "merged"
+	PruneSquashMerged PruneReason = "squash-merged"
+	PruneGone         PruneReason = "gone"
+	PruneStale        PruneReason = "stale"
+)
+
+// PruneCandidate is a worktree that matched at least one prune reason
+type PruneCandidate struct {
+	Status   WorktreeStatus
+	Reasons  []PruneReason
+	Unpushed bool      // Branch has commits that are not on any remote and not merged into the base branch
+	LastUsed time.Time // Most recent of the last commit and the last index update
+}
+
+// Safe reports whether the worktree can be removed without losing work
+func (c PruneCandidate) Safe() bool {
+	return !c.Status.Dirty() && !c.Unpushed && c.Status.Error == ""
+}
+
+// FindPruneCandidates returns the worktrees whose branch is merged into the base branch baseBranch returns for it, whose
+// upstream is gone, or which have not been used for staleAfter (disabled when zero). The worktree of a base branch is
+// never a candidate.
+func (g *Git) FindPruneCandidates(worktrees []Worktree, baseBranch func(branch string) string, staleAfter time.Duration, jobs int) ([]PruneCandidate, error) {
+	goneBranches, err := g.ListGoneBranches()
+	if err != nil {
+		return nil, err
+	}
+	statuses := g.GetWorktreeStatuses(worktrees, baseBranch, jobs)
+
+	results := make([]*PruneCandidate, len(statuses))
+	forEachConcurrently(len(statuses), jobs, func(i int) {
+		results[i] = g.evaluatePruneCandidate(statuses[i], goneBranches, staleAfter)
+	})
+
+	var candidates []PruneCandidate
+	for _, candidate := range results {
+		if candidate != nil {
+			candidates = append(candidates, *candidate)
+		}
+	}
+	return candidates, nil
+}
+
+func (g *Git) evaluatePruneCandidate(status WorktreeStatus, goneBranches map[string]bool, staleAfter time.Duration) *PruneCandidate {
+	branch := status.BranchName()
+	if status.Bare || (branch != "" && branch == status.BaseBranch) {
+		return nil
+	}
+	baseRef := g.resolveBaseRef(status.BaseBranch)
+
+	candidate := PruneCandidate{Status: status, LastUsed: lastUsed(status)}
+	merged := false
+	if branch != "" && baseRef != "" {
+		if g.IsMerged(status.Branch, baseRef) {
+			candidate.Reasons = append(candidate.Reasons, PruneMerged)
+			merged = true
+		} else if g.IsSquashMerged(status.Branch, baseRef) {
+			candidate.Reasons = append(candidate.Reasons, PruneSquashMerged)
+			merged = true
+		}
+	}
+	gone := branch != "" && goneBranches[branch]
+	if gone {
+		candidate.Reasons = append(candidate.Reasons, PruneGone)
+	}
+	if staleAfter > 0 && !candidate.LastUsed.IsZero() && time.Since(candidate.LastUsed) > staleAfter {
+		candidate.Reasons = append(candidate.Reasons, PruneStale)
+	}
+	if len(candidate.Reasons) == 0 {
+		return nil
+	}
+
+	if status.Upstream != "" && !gone {
+		candidate.Unpushed = status.Ahead > 0
+	} else if !merged {
+		candidate.Unpushed = countUnpushedCommits(status.Path) != 0
+	}
+
+	return &candidate
+}
+
+// IsMerged reports whether branch is reachable from base and points at a different commit
+func (g *Git) IsMerged(branch, base string) bool {
+	if g.revParse(branch) == g.revParse(base) {
+		return false
+	}
+	return exec.Command("git", "-C", g.worktreeRoot, "merge-base", "--is-ancestor", branch, base).Run() == nil
+}
+
+// IsSquashMerged reports whether the combined changes of branch were applied to base as a single commit. The patch-id
+// of the branch's changes since the merge base is compared with the patch-ids of the commits on base since then, which
+// writes nothing to the repository.
+func (g *Git) IsSquashMerged(branch, base string) bool {
+	mergeBase, err := exec.Command("git", "-C", g.worktreeRoot, "merge-base", base, branch).Output()
+	if err != nil {
+		return false
+	}
+	mergeBaseCommit := strings.TrimSpace(string(mergeBase))
+	tree := g.revParse(branch + "^{tree}")
+	if tree == "" || tree == g.revParse(mergeBaseCommit+"^{tree}") {
+		return false
+	}
+
+	branchDiff, err := exec.Command("git", "-C", g.worktreeRoot, "diff", mergeBaseCommit, branch).Output()
+	if err != nil {
+		return false
+	}
+	branchPatchIDs := g.patchIDs(branchDiff)
+	if len(branchPatchIDs) == 0 {
+		return false
+	}
+	baseLog, err := exec.Command("git", "-C", g.worktreeRoot, "log", "-p", "--no-merges", mergeBaseCommit+".."+base).Output()
+	if err != nil {
+		return false
+	}
+	return slices.Contains(g.patchIDs(baseLog), branchPatchIDs[0])
+}
+
+// patchIDs returns the patch-id of every patch in the output of git diff or git log -p
+func (g *Git) patchIDs(patches []byte) []string {
+	cmd := exec.Command("git", "-C", g.worktreeRoot, "patch-id", "--stable")
+	cmd.Stdin = bytes.NewReader(patches)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if id, _, ok := strings.Cut(line, " "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ListGoneBranches returns the local branches whose upstream no longer exists on the remote
+func (g *Git) ListGoneBranches() (map[string]bool, error) {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "for-each-ref", "--format=%(refname:short)%00%(upstream:track)", "refs/heads").Output()
+	if err != nil {
+		return nil, commandError(err)
+	}
+
+	gone := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		branch, track, ok := strings.Cut(line, "\x00")
+		if ok && track == "[gone]" {
+			gone[branch] = true
+		}
+	}
+	return gone, nil
+}
+
+func (g *Git) revParse(rev string) string {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "rev-parse", "--verify", "--quiet", rev).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// countUnpushedCommits returns the number of commits in HEAD that are not on any remote, or -1 on failure
+func countUnpushedCommits(dir string) int {
+	output, err := exec.Command("git", "-C", dir, "rev-list", "--count", "HEAD", "--not", "--remotes").Output()
+	if err != nil {
+		return -1
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return -1
+	}
+	return count
+}
+
+// lastUsed returns the most recent of the last commit and the last modification of the worktree's index
+func lastUsed(status WorktreeStatus) time.Time {
+	last := status.LastCommitTime
+	output, err := exec.Command("git", "-C", status.Path, "rev-parse", "--path-format=absolute", "--git-path", "index").Output()
+	if err != nil {
+		return last
+	}
+	if info, err := os.Stat(strings.TrimSpace(string(output))); err == nil && info.ModTime().After(last) {
+		last = info.ModTime()
+	}
+	return last
+}
+
+// FormatReasons joins the reasons for display, e.g. "merged, gone"
+func (c PruneCandidate) FormatReasons() string {
+	parts := make([]string, len(c.Reasons))
+	for i, reason := range c.Reasons {
+		parts[i] = string(reason)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,169 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a repository with a commit on main in a temporary directory
+func newTestRepo(t *testing.T) *Git {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_AUTHOR_NAME", "gwt")
+	t.Setenv("GIT_AUTHOR_EMAIL", "gwt@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "gwt")
+	t.Setenv("GIT_COMMITTER_EMAIL", "gwt@example.com")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	root := t.TempDir()
+	runGit(t, root, "init", "-q", "-b", "main")
+	commitFile(t, root, "README", "readme\n", "initial commit")
+	return &Git{worktreeRoot: root}
+}
+
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func commitFile(t *testing.T, dir string, name string, content string, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", message)
+}
+
+func TestMergeDetection(t *testing.T) {
+	g := newTestRepo(t)
+	root := g.worktreeRoot
+
+	// merged is merged with a merge commit
+	runGit(t, root, "checkout", "-q", "-b", "merged")
+	commitFile(t, root, "merged.txt", "merged\n", "merged")
+	runGit(t, root, "checkout", "-q", "main")
+	runGit(t, root, "merge", "-q", "--no-ff", "-m", "merge merged", "merged")
+
+	// squashed has two commits applied to main as one
+	runGit(t, root, "checkout", "-q", "-b", "squashed")
+	commitFile(t, root, "squashed.txt", "one\n", "squashed one")
+	commitFile(t, root, "squashed.txt", "one\ntwo\n", "squashed two")
+	runGit(t, root, "checkout", "-q", "main")
+	runGit(t, root, "merge", "-q", "--squash", "squashed")
+	runGit(t, root, "commit", "-q", "-m", "squash squashed")
+
+	// partial only had its first commit cherry-picked
+	runGit(t, root, "checkout", "-q", "-b", "partial")
+	commitFile(t, root, "partial.txt", "one\n", "partial one")
+	first := runGit(t, root, "rev-parse", "HEAD")
+	commitFile(t, root, "partial.txt", "one\ntwo\n", "partial two")
+	runGit(t, root, "checkout", "-q", "main")
+	runGit(t, root, "cherry-pick", first)
+
+	// open is not on main at all
+	runGit(t, root, "checkout", "-q", "-b", "open")
+	commitFile(t, root, "open.txt", "open\n", "open")
+	runGit(t, root, "checkout", "-q", "main")
+
+	// main moves on after the squash, and empty has nothing but main
+	commitFile(t, root, "later.txt", "later\n", "later")
+	runGit(t, root, "branch", "empty")
+
+	tests := []struct {
+		branch       string
+		merged       bool
+		squashMerged bool
+	}{
+		{branch: "merged", merged: true},
+		{branch: "squashed", squashMerged: true},
+		{branch: "partial"},
+		{branch: "open"},
+		{branch: "empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := g.IsMerged(tt.branch, "main"); got != tt.merged {
+				t.Errorf("IsMerged(%s) = %t, want %t", tt.branch, got, tt.merged)
+			}
+			if got := g.IsSquashMerged(tt.branch, "main"); got != tt.squashMerged {
+				t.Errorf("IsSquashMerged(%s) = %t, want %t", tt.branch, got, tt.squashMerged)
+			}
+		})
+	}
+}
+
+func TestIsSquashMergedWritesNothing(t *testing.T) {
+	g := newTestRepo(t)
+	root := g.worktreeRoot
+	runGit(t, root, "checkout", "-q", "-b", "feature")
+	commitFile(t, root, "feature.txt", "feature\n", "feature")
+	runGit(t, root, "checkout", "-q", "main")
+	commitFile(t, root, "main.txt", "main\n", "main")
+
+	before := runGit(t, root, "count-objects", "-v")
+	if g.IsSquashMerged("feature", "main") {
+		t.Fatal("IsSquashMerged(feature) = true, want false")
+	}
+	if after := runGit(t, root, "count-objects", "-v"); after != before {
+		t.Errorf("objects changed from\n%s\nto\n%s", before, after)
+	}
+}
+
+func TestFindPruneCandidatesBaseBranchPerBranch(t *testing.T) {
+	g := newTestRepo(t)
+	root := g.worktreeRoot
+	runGit(t, root, "checkout", "-q", "-b", "develop")
+	commitFile(t, root, "develop.txt", "develop\n", "develop")
+	runGit(t, root, "checkout", "-q", "main")
+	for _, branch := range []string{"feature/merged", "hotfix/merged", "feature/open"} {
+		runGit(t, root, "branch", branch, "main")
+	}
+	runGit(t, root, "checkout", "-q", "--detach")
+
+	for _, branch := range []string{"feature/merged", "hotfix/merged", "feature/open"} {
+		path := filepath.Join(root, branch)
+		runGit(t, root, "worktree", "add", "-q", path, branch)
+		commitFile(t, path, "change.txt", branch+"\n", branch)
+	}
+	runGit(t, root, "checkout", "-q", "develop")
+	runGit(t, root, "merge", "-q", "--no-ff", "-m", "merge feature", "feature/merged")
+	runGit(t, root, "checkout", "-q", "main")
+	runGit(t, root, "merge", "-q", "--no-ff", "-m", "merge hotfix", "hotfix/merged")
+
+	worktrees, err := g.ListWorktrees()
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseBranch := func(branch string) string {
+		if strings.HasPrefix(branch, "feature/") {
+			return "develop"
+		}
+		return "main"
+	}
+	candidates, err := g.FindPruneCandidates(worktrees, baseBranch, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var merged []string
+	for _, candidate := range candidates {
+		if slices.Contains(candidate.Reasons, PruneMerged) {
+			merged = append(merged, candidate.Status.BranchName()+" into "+candidate.Status.BaseBranch)
+		}
+	}
+	want := []string{"feature/merged into develop", "hotfix/merged into main"}
+	if !slices.Equal(merged, want) {
+		t.Errorf("merged candidates = %q, want %q", merged, want)
+	}
+}
@@ -0,0 +1,220 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorktreeStatus summarizes the state of a single worktree
+type WorktreeStatus struct {
+	Worktree
+	Staged            int       `json:"staged"`              // Files with staged changes
+	Unstaged          int       `json:"unstaged"`            // Files with unstaged changes
+	Untracked         int       `json:"untracked"`           // Untracked files
+	Conflicts         int       `json:"conflicts"`           // Files with merge conflicts
+	Upstream          string    `json:"upstream,omitempty"`  // Upstream branch, e.g. origin/main
+	Ahead             int       `json:"ahead"`               // Commits ahead of upstream
+	Behind            int       `json:"behind"`              // Commits behind upstream
+	BaseBranch        string    `json:"base_branch"`         // Branch used for the base comparison
+	BaseAhead         int       `json:"base_ahead"`          // Commits ahead of the base branch
+	BaseBehind        int       `json:"base_behind"`         // Commits behind the base branch
+	LastCommitSubject string    `json:"last_commit_subject"` // Subject of the HEAD commit
+	LastCommitTime    time.Time `json:"last_commit_time"`    // Committer date of the HEAD commit
+	Stashes           int       `json:"stashes"`             // Stash entries created on the worktree's branch
+	Error             string    `json:"error,omitempty"`     // Error encountered while gathering the status
+}
+
+// Dirty reports whether the worktree has staged, unstaged, untracked or conflicting files
+func (s WorktreeStatus) Dirty() bool {
+	return s.Staged+s.Unstaged+s.Untracked+s.Conflicts > 0
+}
+
+// GetWorktreeStatuses gathers the status of every worktree using at most jobs concurrent workers.
+// Statuses are returned in the same order as worktrees. Failures are recorded in WorktreeStatus.Error. Each worktree is
+// compared with the base branch baseBranch returns for its branch.
+func (g *Git) GetWorktreeStatuses(worktrees []Worktree, baseBranch func(branch string) string, jobs int) []WorktreeStatus {
+	stashes, _ := g.countStashesByBranch()
+
+	statuses := make([]WorktreeStatus, len(worktrees))
+	forEachConcurrently(len(worktrees), jobs, func(i int) {
+		status, err := g.GetWorktreeStatus(worktrees[i], baseBranch(worktrees[i].BranchName()))
+		if err != nil {
+			status.Error = err.Error()
+		}
+		status.Stashes = stashes[worktrees[i].BranchName()]
+		statuses[i] = status
+	})
+
+	return statuses
+}
+
+// GetWorktreeStatus gathers the status of a single worktree, excluding the stash count
+func (g *Git) GetWorktreeStatus(wt Worktree, baseBranch string) (WorktreeStatus, error) {
+	status := WorktreeStatus{Worktree: wt, BaseBranch: baseBranch}
+	if wt.Prunable {
+		return status, fmt.Errorf("worktree is prunable: %s", wt.PrunableReason)
+	}
+
+	output, err := exec.Command("git", "--no-optional-locks", "-C", wt.Path, "status", "--porcelain=v2", "--branch").Output()
+	if err != nil {
+		return status, commandError(err)
+	}
+	parseStatus(string(output), &status)
+
+	output, err = exec.Command("git", "-C", wt.Path, "log", "-1", "--format=%ct%x00%s").Output()
+	if err != nil {
+		return status, commandError(err)
+	}
+	if timestamp, subject, ok := strings.Cut(strings.TrimSpace(string(output)), "\x00"); ok {
+		if seconds, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+			status.LastCommitTime = time.Unix(seconds, 0)
+		}
+		status.LastCommitSubject = subject
+	}
+
+	if baseRef := g.resolveBaseRef(baseBranch); baseRef != "" && wt.BranchName() != baseBranch {
+		ahead, behind, err := countAheadBehind(wt.Path, baseRef, "HEAD")
+		if err != nil {
+			return status, err
+		}
+		status.BaseAhead, status.BaseBehind = ahead, behind
+	}
+
+	return status, nil
+}
+
+// parseStatus fills the change counts and upstream tracking info from `git status --porcelain=v2 --branch`
+func parseStatus(output string, status *WorktreeStatus) {
+	for _, line := range strings.Split(output, "\n") {
+		switch {
+		case strings.HasPrefix(line, "# branch.upstream "):
+			status.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				status.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				status.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "):
+			if len(line) < 4 {
+				continue
+			}
+			if line[2] != '.' {
+				status.Staged++
+			}
+			if line[3] != '.' {
+				status.Unstaged++
+			}
+		case strings.HasPrefix(line, "u "):
+			status.Conflicts++
+		case strings.HasPrefix(line, "? "):
+			status.Untracked++
+		}
+	}
+}
+
+// resolveBaseRef returns the local base branch, falling back to its remote tracking branch
+func (g *Git) resolveBaseRef(baseBranch string) string {
+	for _, ref := range []string{"refs/heads/" + baseBranch, "refs/remotes/origin/" + baseBranch} {
+		if err := exec.Command("git", "-C", g.worktreeRoot, "rev-parse", "--verify", "--quiet", ref).Run(); err == nil {
+			return ref
+		}
+	}
+	return ""
+}
+
+// countAheadBehind returns how many commits head is ahead and behind of base
+func countAheadBehind(dir, base, head string) (int, int, error) {
+	output, err := exec.Command("git", "-C", dir, "rev-list", "--left-right", "--count", fmt.Sprintf("%s...%s", head, base)).Output()
+	if err != nil {
+		return 0, 0, commandError(err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return 0, 0, errors.New("could not parse rev-list output")
+	}
+	ahead, _ := strconv.Atoi(fields[0])
+	behind, _ := strconv.Atoi(fields[1])
+	return ahead, behind, nil
+}
+
+// countStashesByBranch counts stash entries by the branch they were created on
+func (g *Git) countStashesByBranch() (map[string]int, error) {
+	output, err := exec.Command("git", "-C", g.worktreeRoot, "stash", "list", "--format=%gs").Output()
+	if err != nil {
+		return nil, commandError(err)
+	}
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		// Stash subjects look like "WIP on <branch>: <sha> <subject>" or "On <branch>: <message>"
+		var rest string
+		if after, ok := strings.CutPrefix(line, "WIP on "); ok {
+			rest = after
+		} else if after, ok := strings.CutPrefix(line, "On "); ok {
+			rest = after
+		} else {
+			continue
+		}
+		if branch, _, ok := strings.Cut(rest, ":"); ok {
+			counts[branch]++
+		}
+	}
+	return counts, nil
+}
+
+// IsDirty reports whether the worktree at path has staged, unstaged or untracked changes
+func IsDirty(path string) (bool, error) {
+	output, err := exec.Command("git", "--no-optional-locks", "-C", path, "status", "--porcelain").Output()
+	if err != nil {
+		return false, commandError(err)
+	}
+	return strings.TrimSpace(string(output)) != "", nil
+}
+
+// CheckDirty reports for each worktree whether it has uncommitted changes, using at most jobs concurrent workers.
+// Prunable worktrees are reported as clean.
+func CheckDirty(worktrees []Worktree, jobs int) ([]bool, error) {
+	dirty := make([]bool, len(worktrees))
+	errs := make([]error, len(worktrees))
+	forEachConcurrently(len(worktrees), jobs, func(i int) {
+		if !worktrees[i].Prunable {
+			dirty[i], errs[i] = IsDirty(worktrees[i].Path)
+		}
+	})
+	return dirty, errors.Join(errs...)
+}
+
+// forEachConcurrently calls fn for every index in [0, n) using at most jobs goroutines
+func forEachConcurrently(n int, jobs int, fn func(i int)) {
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(jobs, 1), n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+}
+
+// commandError converts an exec error into an error carrying git's stderr
+func commandError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return errors.New(strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return err
+}
@@ -0,0 +1,207 @@
+package git
+
+import (
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Worktree describes a single entry of `git worktree list --porcelain -z`
+type Worktree struct {
+	Path           string `json:"path"`            // Absolute path of the worktree
+	Name           string `json:"name"`            // Path relative to the worktree root
+	Head           string `json:"head"`            // Commit SHA checked out in the worktree
+	Branch         string `json:"branch"`          // Full ref name, e.g. refs/heads/main (empty when detached or bare)
+	Detached       bool   `json:"detached"`        // HEAD is detached
+	Bare           bool   `json:"bare"`            // Entry is the bare repository
+	Locked         bool   `json:"locked"`          // Worktree is locked with `git worktree lock`
+	LockedReason   string `json:"locked_reason"`   // Reason given when the worktree was locked
+	Prunable       bool   `json:"prunable"`        // Worktree can be pruned with `git worktree prune`
+	PrunableReason string `json:"prunable_reason"` // Reason git reports the worktree as prunable
+}
+
+// BranchName returns the short branch name, e.g. main for refs/heads/main
+func (w Worktree) BranchName() string {
+	return strings.TrimPrefix(w.Branch, "refs/heads/")
+}
+
+// CreatedTime returns when the worktree was added, based on the administrative files git writes once on creation
+func (w Worktree) CreatedTime() (time.Time, error) {
+	adminDir := filepath.Join(w.Path, ".git")
+	info, err := os.Stat(adminDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if info.IsDir() {
+		return info.ModTime(), nil
+	}
+
+	content, err := os.ReadFile(adminDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	adminDir = strings.TrimSpace(strings.TrimPrefix(string(content), "gitdir:"))
+	if !filepath.IsAbs(adminDir) {
+		adminDir = filepath.Join(w.Path, adminDir)
+	}
+	info, err = os.Stat(filepath.Join(adminDir, "commondir"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// ListWorktreeNames returns the relative names of the linked worktrees, e.g. for selection or completion
+func (g *Git) ListWorktreeNames() ([]string, error) {
+	worktrees, err := g.ListWorktrees()
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(worktrees))
+	for _, wt := range worktrees {
+		names = append(names, wt.Name)
+	}
+	return names, nil
+}
+
+// listAllWorktrees returns every worktree known to git, including the main worktree or bare repository
+func (g *Git) listAllWorktrees() ([]Worktree, error) {
+	cmdArgs := []string{"worktree", "list", "--porcelain", "-z"}
+	if g.worktreeRoot != "" {
+		cmdArgs = append([]string{"-C", g.worktreeRoot}, cmdArgs...)
+	}
+	output, err := exec.Command("git", cmdArgs...).Output()
+	if err != nil {
+		return nil, commandError(err)
+	}
+
+	return parseWorktrees(string(output), g.worktreeRoot), nil
+}
+
+// parseWorktrees parses NUL separated porcelain output. Each attribute is terminated by a NUL and
+// records are separated by an additional NUL.
+func parseWorktrees(output string, root string) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+
+	for _, field := range strings.Split(output, "\x00") {
+		if field == "" {
+			if current != nil {
+				worktrees = append(worktrees, *current)
+				current = nil
+			}
+			continue
+		}
+
+		key, value, _ := strings.Cut(field, " ")
+		if key == "worktree" {
+			if current != nil {
+				worktrees = append(worktrees, *current)
+			}
+			current = &Worktree{Path: value, Name: relativeName(root, value)}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = value
+		case "detached":
+			current.Detached = true
+		case "bare":
+			current.Bare = true
+		case "locked":
+			current.Locked = true
+			current.LockedReason = value
+		case "prunable":
+			current.Prunable = true
+			current.PrunableReason = value
+		}
+	}
+	if current != nil {
+		worktrees = append(worktrees, *current)
+	}
+
+	return worktrees
+}
+
+func relativeName(root string, path string) string {
+	if root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+func isSamePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// WorktreePathFor returns the path worktree_path gives the branch's worktree
+func (g *Git) WorktreePathFor(branch string) (string, error) {
+	return g.pathConfig().ForBranch(branch).ResolveWorktreePath(g.worktreeRoot)
+}
+
+// pathConfig returns the configuration used to resolve worktree paths. An invalid configuration falls back to the
+// defaults so worktrees can still be listed and removed.
+func (g *Git) pathConfig() *_config.Config {
+	if g.config == nil {
+		config, err := _config.LoadConfig(g.worktreeRoot)
+		if err != nil {
+			config = &_config.Config{}
+			_ = config.Validate()
+		}
+		g.config = config
+	}
+	return g.config
+}
+
+// checkWorktreePath reports an error if a new worktree at path would collide with an existing directory or worktree,
+// including paths that only differ in case, which are the same directory on case-insensitive file systems
+func (g *Git) checkWorktreePath(path string) error {
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if entries, err := os.ReadDir(filepath.Dir(path)); err == nil {
+		for _, entry := range entries {
+			if strings.EqualFold(entry.Name(), filepath.Base(path)) {
+				return fmt.Errorf("%s collides with %s, which only differs in case", path, filepath.Join(filepath.Dir(path), entry.Name()))
+			}
+		}
+	}
+
+	worktrees, err := g.listAllWorktrees()
+	if err != nil {
+		return err
+	}
+	for _, wt := range worktrees {
+		if wt.Bare || isSamePath(wt.Path, g.worktreeRoot) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(filepath.Clean(wt.Path), filepath.Clean(path)):
+			return fmt.Errorf("%s collides with the worktree at %s, which only differs in case", path, wt.Path)
+		case isWithinPath(path, wt.Path):
+			return fmt.Errorf("%s is inside the worktree at %s", path, wt.Path)
+		case isWithinPath(wt.Path, path):
+			return fmt.Errorf("%s would contain the worktree at %s", path, wt.Path)
+		}
+	}
+	return nil
+}
+
+func isWithinPath(path, dir string) bool {
+	return strings.HasPrefix(filepath.Clean(path), filepath.Clean(dir)+string(filepath.Separator))
+}
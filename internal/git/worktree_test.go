@@ -0,0 +1,98 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// porcelain joins worktree records the way `git worktree list --porcelain -z` prints them
+func porcelain(records ...[]string) string {
+	var output strings.Builder
+	for _, record := range records {
+		for _, field := range record {
+			output.WriteString(field + "\x00")
+		}
+		output.WriteString("\x00")
+	}
+	return output.String()
+}
+
+func TestParseWorktrees(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		root   string
+		want   []Worktree
+	}{
+		{
+			name:   "empty",
+			output: "",
+			root:   "/repo",
+			want:   nil,
+		},
+		{
+			name: "bare repository and linked worktrees",
+			output: porcelain(
+				[]string{"worktree /repo/.bare", "bare"},
+				[]string{"worktree /repo/main", "HEAD 1111111111111111111111111111111111111111", "branch refs/heads/main"},
+				[]string{"worktree /repo/feature/login", "HEAD 2222222222222222222222222222222222222222", "branch refs/heads/feature/login"},
+			),
+			root: "/repo",
+			want: []Worktree{
+				{Path: "/repo/.bare", Name: ".bare", Bare: true},
+				{Path: "/repo/main", Name: "main", Head: "1111111111111111111111111111111111111111", Branch: "refs/heads/main"},
+				{Path: "/repo/feature/login", Name: "feature/login", Head: "2222222222222222222222222222222222222222", Branch: "refs/heads/feature/login"},
+			},
+		},
+		{
+			name: "detached, locked and prunable",
+			output: porcelain(
+				[]string{"worktree /repo/detached", "HEAD 3333333333333333333333333333333333333333", "detached"},
+				[]string{"worktree /repo/locked", "HEAD 4444444444444444444444444444444444444444", "branch refs/heads/locked", "locked on a usb drive"},
+				[]string{"worktree /repo/unreasoned", "HEAD 5555555555555555555555555555555555555555", "branch refs/heads/unreasoned", "locked"},
+				[]string{"worktree /repo/gone", "HEAD 6666666666666666666666666666666666666666", "branch refs/heads/gone", "prunable gitdir file points to non-existent location"},
+			),
+			root: "/repo",
+			want: []Worktree{
+				{Path: "/repo/detached", Name: "detached", Head: "3333333333333333333333333333333333333333", Detached: true},
+				{Path: "/repo/locked", Name: "locked", Head: "4444444444444444444444444444444444444444", Branch: "refs/heads/locked", Locked: true, LockedReason: "on a usb drive"},
+				{Path: "/repo/unreasoned", Name: "unreasoned", Head: "5555555555555555555555555555555555555555", Branch: "refs/heads/unreasoned", Locked: true},
+				{Path: "/repo/gone", Name: "gone", Head: "6666666666666666666666666666666666666666", Branch: "refs/heads/gone", Prunable: true, PrunableReason: "gitdir file points to non-existent location"},
+			},
+		},
+		{
+			name:   "path with spaces outside of the root",
+			output: porcelain([]string{"worktree /elsewhere/my worktree", "HEAD 7777777777777777777777777777777777777777", "branch refs/heads/spaces"}),
+			root:   "/repo",
+			want: []Worktree{
+				{Path: "/elsewhere/my worktree", Name: "../elsewhere/my worktree", Head: "7777777777777777777777777777777777777777", Branch: "refs/heads/spaces"},
+			},
+		},
+		{
+			name:   "without a root the name is the path",
+			output: porcelain([]string{"worktree /repo/main", "HEAD 8888888888888888888888888888888888888888", "branch refs/heads/main"}),
+			root:   "",
+			want: []Worktree{
+				{Path: "/repo/main", Name: "/repo/main", Head: "8888888888888888888888888888888888888888", Branch: "refs/heads/main"},
+			},
+		},
+		{
+			name:   "last record without the closing separator",
+			output: "worktree /repo/main\x00HEAD 9999999999999999999999999999999999999999\x00branch refs/heads/main",
+			root:   "/repo",
+			want: []Worktree{
+				{Path: "/repo/main", Name: "main", Head: "9999999999999999999999999999999999999999", Branch: "refs/heads/main"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseWorktrees(tt.output, tt.root)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWorktrees() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
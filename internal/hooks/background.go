@@ -0,0 +1,228 @@
+package hooks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/state"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// SetupStatus is the progress of the background hooks of a worktree
+type SetupStatus string
+
+const (
+	SetupRunning SetupStatus = "setting up"
+	SetupReady   SetupStatus = "ready"
+	SetupFailed  SetupStatus = "failed"
+)
+
+// Stage is the hooks of an event that run in the background
+type Stage struct {
+	Event string           `json:"event"`
+	Hooks _config.HookList `json:"hooks"`
+}
+
+// Setup is the state of the background hooks of a worktree, kept in a state file
+type Setup struct {
+	Status   SetupStatus          `json:"status"`
+	PID      int                  `json:"pid,omitempty"`
+	Started  time.Time            `json:"started"`
+	Finished time.Time            `json:"finished,omitzero"`
+	Error    string               `json:"error,omitempty"`
+	Stages   []Stage              `json:"stages"`
+	Context  utils.CommandContext `json:"context"`
+	Jobs     int                  `json:"jobs,omitempty"`
+	Notify   string               `json:"notify,omitempty"`
+}
+
+// setupPath returns the path of the state file of the worktree's background hooks
+func setupPath(repoRoot string, worktree string) (string, error) {
+	stateDir, err := state.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "setup", state.RepoKey(repoRoot), url.PathEscape(worktree)+".json"), nil
+}
+
+// StartBackground runs the stages in a detached gwt process, then the notify command
+func StartBackground(stages []Stage, ctx utils.CommandContext, jobs int, notify string) error {
+	if utils.DryRun() {
+		for _, stage := range stages {
+			utils.RecordDryRun("start the %s hooks in the background", stage.Event)
+			ctx.Event = stage.Event
+			if err := Run(stage.Hooks, ctx, io.Discard, jobs); err != nil {
+				return err
+			}
+		}
+		if notify != "" {
+			utils.RecordDryRun("notify when they finish: %s", notify)
+		}
+		return nil
+	}
+	path, err := setupPath(ctx.RepoRoot, ctx.Name)
+	if err != nil {
+		return err
+	}
+	setup := &Setup{Status: SetupRunning, Started: time.Now(), Stages: stages, Context: ctx, Jobs: jobs, Notify: notify}
+	if err := setup.save(path); err != nil {
+		return err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	execCmd := exec.Command(executable, "hooks", "__background", path)
+	execCmd.Dir = ctx.RepoRoot
+	// Without stdin, stdout and stderr the process does not hold on to the terminal
+	detach(execCmd)
+	if err := execCmd.Start(); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("error starting background hooks: %w", err)
+	}
+	return execCmd.Process.Release()
+}
+
+// RunBackground runs the stages of the state file at path for the process started by StartBackground
+func RunBackground(path string) error {
+	setup, err := readSetup(path)
+	if err != nil {
+		return err
+	}
+	setup.PID = os.Getpid()
+	if err := setup.save(path); err != nil {
+		return err
+	}
+
+	var runErr error
+	for _, stage := range setup.Stages {
+		ctx := setup.Context
+		ctx.Event = stage.Event
+		if runErr = Run(stage.Hooks, ctx, io.Discard, setup.Jobs); runErr != nil {
+			break
+		}
+	}
+
+	setup.Status = SetupReady
+	if runErr != nil {
+		setup.Status = SetupFailed
+		setup.Error = runErr.Error()
+	}
+	setup.Finished = time.Now()
+	if err := setup.save(path); err != nil {
+		return errors.Join(runErr, err)
+	}
+
+	if setup.Notify != "" {
+		if err := setup.notify(); err != nil {
+			return errors.Join(runErr, fmt.Errorf("error running notify command: %w", err))
+		}
+	}
+	return runErr
+}
+
+func (s *Setup) notify() error {
+	command, err := utils.ExpandCommand(s.Notify, s.Context)
+	if err != nil {
+		return err
+	}
+	execCmd := exec.Command("sh", "-c", command)
+	execCmd.Dir = s.Context.RepoRoot
+	execCmd.Env = append(append(os.Environ(), s.Context.Env()...), "GWT_SETUP_STATUS="+string(s.Status))
+	if output, err := execCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+	return nil
+}
+
+// LoadSetup returns the state of the worktree's background hooks, or nil if none ran for it. Hooks whose process is
+// gone are reported as failed.
+func LoadSetup(repoRoot string, worktree string) (*Setup, error) {
+	path, err := setupPath(repoRoot, worktree)
+	if err != nil {
+		return nil, err
+	}
+	setup, err := readSetup(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if setup.Status == SetupRunning && setup.PID != 0 && !processRunning(setup.PID) {
+		setup.Status = SetupFailed
+		setup.Error = "stopped before the hooks finished"
+	}
+	return setup, nil
+}
+
+// RemoveSetup forgets the state of the worktree's background hooks, e.g. when the worktree is removed
+func RemoveSetup(repoRoot string, worktree string) error {
+	if utils.DryRun() {
+		return nil
+	}
+	path, err := setupPath(repoRoot, worktree)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// MoveSetup moves the state of the worktree's background hooks to the worktree's new name, e.g. when it is renamed
+func MoveSetup(repoRoot string, worktree string, newWorktree string) error {
+	if utils.DryRun() {
+		return nil
+	}
+	oldPath, err := setupPath(repoRoot, worktree)
+	if err != nil {
+		return err
+	}
+	newPath, err := setupPath(repoRoot, newWorktree)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func readSetup(path string) (*Setup, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var setup Setup
+	if err := json.Unmarshal(data, &setup); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return &setup, nil
+}
+
+// save writes the state file through a temporary file, so readers never see it half written
+func (s *Setup) save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
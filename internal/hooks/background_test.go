@@ -0,0 +1,33 @@
+package hooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMoveSetup(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	path, err := setupPath("/work/repo", "feature/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup := &Setup{Status: SetupReady, Started: time.Now(), Finished: time.Now()}
+	if err := setup.save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveSetup("/work/repo", "feature/login", "feature/sign-in"); err != nil {
+		t.Fatal(err)
+	}
+	if old, err := LoadSetup("/work/repo", "feature/login"); err != nil || old != nil {
+		t.Errorf("LoadSetup(feature/login) = %+v, %v, want nothing after the move", old, err)
+	}
+	moved, err := LoadSetup("/work/repo", "feature/sign-in")
+	if err != nil || moved == nil || moved.Status != SetupReady {
+		t.Errorf("LoadSetup(feature/sign-in) = %+v, %v, want the moved state", moved, err)
+	}
+
+	if err := MoveSetup("/work/repo", "main", "trunk"); err != nil {
+		t.Errorf("MoveSetup() without a state = %v, want no error", err)
+	}
+}
@@ -0,0 +1,336 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/logs"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// Status is the outcome of a hook
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusFailed   Status = "failed"
+	StatusTimedOut Status = "timed out"
+	StatusSkipped  Status = "skipped"
+	// StatusNotRun is the status of hooks that were not started because another hook failed
+	StatusNotRun Status = "not run"
+)
+
+// Result is the outcome of a single hook
+type Result struct {
+	Name     string
+	Status   Status
+	ExitCode int // -1 when the command did not run or did not exit on its own
+	Duration time.Duration
+	Reason   string // Why the hook was skipped or failed
+}
+
+// prepared is a hook with its templates expanded
+type prepared struct {
+	hook    _config.Hook
+	name    string
+	command string
+	dir     string
+	env     []string
+	timeout time.Duration
+}
+
+// Run runs the hooks of the context's event with at most jobs at a time, or one per CPU when jobs is zero. A failing
+// hook keeps further hooks from starting unless it sets continue_on_error.
+func Run(hooks _config.HookList, ctx utils.CommandContext, out io.Writer, jobs int) error {
+	steps := make([]prepared, 0, len(hooks))
+	for i, hook := range hooks {
+		step, err := prepare(hook, ctx)
+		if err != nil {
+			return err
+		}
+		step.name = displayName(hook, step.command, i)
+		steps = append(steps, step)
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	if utils.DryRun() {
+		for _, step := range steps {
+			step.recordDryRun(ctx)
+		}
+		return nil
+	}
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+
+	// Hooks still run when the log cannot be written, e.g. on a read-only home directory
+	log, err := logs.Create(ctx.RepoRoot, ctx.Name, ctx.Event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot write the log of the %s hooks: %s\n", ctx.Event, err)
+	}
+	defer log.Close()
+
+	concurrent := hooks.Concurrent()
+	var output *prefixedOutput
+	if concurrent {
+		names := make([]string, len(steps))
+		for i, step := range steps {
+			names[i] = step.name
+		}
+		output = newPrefixedOutput(names)
+	}
+	printLine := func(i int, message string) {
+		if concurrent {
+			output.printLine(out, i, message)
+		} else {
+			fmt.Fprintln(out, message)
+		}
+	}
+
+	boldStyle := lipgloss.NewStyle().Bold(true)
+	faintStyle := lipgloss.NewStyle().Faint(true)
+	greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+	orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
+
+	dependencies := hooks.Dependencies()
+	results := make([]Result, len(steps))
+	started := make([]bool, len(steps))
+	finished := make([]bool, len(steps))
+	done := make(chan int)
+	var errs []error
+	running, remaining := 0, len(steps)
+
+	// startReady starts the hooks whose dependencies have finished while workers are free
+	startReady := func() {
+		for changed := true; changed; {
+			changed = false
+			for i, step := range steps {
+				if started[i] || running >= jobs || len(errs) > 0 {
+					continue
+				}
+				if slices.ContainsFunc(dependencies[i], func(j int) bool { return !finished[j] }) {
+					continue
+				}
+				started[i] = true
+				changed = true
+
+				if reason := SkipReason(step.hook, ctx); reason != "" {
+					results[i] = Result{Name: step.name, Status: StatusSkipped, ExitCode: -1, Reason: reason}
+					log.Printf(step.name, "skipped %s (%s)", step.command, reason)
+					printLine(i, faintStyle.Render(fmt.Sprintf("Skipping %s hook %d of %d: %s (%s)",
+						ctx.Event, i+1, len(steps), step.command, reason)))
+					finished[i] = true
+					remaining--
+					continue
+				}
+
+				printLine(i, boldStyle.Render(fmt.Sprintf("️➡️ Running %s hook %d of %d in worktree %s: %s...",
+					ctx.Event, i+1, len(steps), orangeStyle.Render(ctx.Name), greenStyle.Render(step.command))))
+				log.Printf(step.name, "running %s in %s", step.command, step.dir)
+				logStdout, logStderr := log.Writer(step.name, false), log.Writer(step.name, true)
+				stdout, stderr, stdin := out, io.Writer(os.Stderr), io.Reader(os.Stdin)
+				if concurrent {
+					// Hooks running in parallel cannot share the terminal's input
+					stdout, stderr, stdin = output.writer(out, i), output.writer(os.Stderr, i), nil
+				}
+				running++
+				go func() {
+					result := step.run(io.MultiWriter(stdout, logStdout), io.MultiWriter(stderr, logStderr), stdin)
+					if concurrent {
+						output.flush(i)
+					}
+					logStdout.Flush()
+					logStderr.Flush()
+					log.Printf(step.name, "%s with exit code %d after %s", result.Status, result.ExitCode, formatDuration(result.Duration))
+					results[i] = result
+					done <- i
+				}()
+			}
+		}
+	}
+
+	for remaining > 0 {
+		startReady()
+		if running == 0 {
+			// A hook failed, so the hooks that have not started yet never will
+			for i, step := range steps {
+				if !started[i] {
+					results[i] = Result{Name: step.name, Status: StatusNotRun, ExitCode: -1}
+					log.Printf(step.name, "not run because another hook failed")
+				}
+			}
+			break
+		}
+
+		i := <-done
+		running--
+		remaining--
+		finished[i] = true
+		if results[i].Status == StatusOK {
+			continue
+		}
+		err := fmt.Errorf("error running %s hook '%s': %s", ctx.Event, steps[i].command, results[i].Reason)
+		if steps[i].hook.ContinueOnError {
+			printLine(i, orangeStyle.Render(fmt.Sprintf("%s. Continuing because of continue_on_error", err)))
+			continue
+		}
+		errs = append(errs, err)
+	}
+
+	if len(steps) > 1 {
+		fmt.Fprintln(out)
+		printSummary(out, results)
+	}
+	if len(errs) > 0 && log != nil {
+		fmt.Fprintln(os.Stderr, faintStyle.Render(fmt.Sprintf("The output of the hooks is kept in %s", log.Path())))
+	}
+	return errors.Join(errs...)
+}
+
+// SkipReason returns why the hook's if conditions keep it from running in the context, or an empty string if it runs
+func SkipReason(hook _config.Hook, ctx utils.CommandContext) string {
+	if hook.If.Branch != "" {
+		if matched, _ := path.Match(hook.If.Branch, ctx.Branch); !matched {
+			return fmt.Sprintf("branch does not match %s", hook.If.Branch)
+		}
+	}
+	if hook.If.Exists != "" {
+		if _, err := os.Stat(resolvePath(hook.If.Exists, defaultDir(ctx))); err != nil {
+			return fmt.Sprintf("%s does not exist", hook.If.Exists)
+		}
+	}
+	return ""
+}
+
+func prepare(hook _config.Hook, ctx utils.CommandContext) (prepared, error) {
+	step := prepared{hook: hook, dir: defaultDir(ctx), env: ctx.Env()}
+	var err error
+	if step.command, err = utils.ExpandCommand(hook.Run, ctx); err != nil {
+		return step, err
+	}
+	if hook.Dir != "" {
+		dir, err := utils.ExpandCommand(hook.Dir, ctx)
+		if err != nil {
+			return step, err
+		}
+		step.dir = resolvePath(dir, step.dir)
+	}
+
+	names := make([]string, 0, len(hook.Env))
+	for name := range hook.Env {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		value, err := utils.ExpandCommand(hook.Env[name], ctx)
+		if err != nil {
+			return step, err
+		}
+		step.env = append(step.env, name+"="+value)
+	}
+
+	if step.timeout, err = hook.TimeoutDuration(); err != nil {
+		return step, err
+	}
+	return step, nil
+}
+
+func (p prepared) run(stdout, stderr io.Writer, stdin io.Reader) Result {
+	runCtx := context.Background()
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(runCtx, p.timeout)
+		defer cancel()
+	}
+
+	execCmd := exec.CommandContext(runCtx, "sh", "-c", p.command)
+	execCmd.Dir = p.dir
+	execCmd.Env = append(os.Environ(), p.env...)
+	execCmd.Stdout = stdout
+	execCmd.Stderr = stderr
+	execCmd.Stdin = stdin
+	if p.timeout > 0 {
+		// A process group of its own keeps the hook off the terminal, so only hooks that can time out get one
+		killProcessGroup(execCmd)
+	}
+	// Commands started in the background can keep the output open after the hook is stopped
+	execCmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := execCmd.Run()
+	result := Result{Name: p.name, Status: StatusOK, ExitCode: -1, Duration: time.Since(start)}
+	if execCmd.ProcessState != nil {
+		result.ExitCode = execCmd.ProcessState.ExitCode()
+	}
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		result.Status = StatusTimedOut
+		result.Reason = fmt.Sprintf("timed out after %s", p.timeout)
+	case err != nil:
+		result.Status = StatusFailed
+		result.Reason = err.Error()
+	}
+	return result
+}
+
+// recordDryRun prints the command the hook would run, or why it would be skipped
+func (p prepared) recordDryRun(ctx utils.CommandContext) {
+	label := ctx.Event + " hook"
+	if p.hook.Name != "" {
+		label += " " + p.hook.Name
+	}
+	if reason := SkipReason(p.hook, ctx); reason != "" {
+		utils.RecordDryRun("skip %s: %s (%s)", label, p.command, reason)
+		return
+	}
+	args := []string{"sh", "-c", p.command}
+	if hookEnv := p.env[len(ctx.Env()):]; len(hookEnv) > 0 {
+		args = append(append([]string{"env"}, hookEnv...), args...)
+	}
+	execCmd := exec.Command(args[0], args[1:]...)
+	execCmd.Dir = p.dir
+	utils.RecordDryRun("%s: %s", label, utils.FormatCommand(execCmd))
+}
+
+// displayName is the hook's name, or the start of its command
+func displayName(hook _config.Hook, command string, i int) string {
+	if hook.Name != "" {
+		return hook.Name
+	}
+	if command == "" {
+		return fmt.Sprintf("hook %d", i+1)
+	}
+	if runes := []rune(command); len(runes) > 24 {
+		return string(runes[:23]) + "…"
+	}
+	return command
+}
+
+// defaultDir is the worktree, or the repository root when the worktree does not exist. A dry run goes by the event
+func defaultDir(ctx utils.CommandContext) string {
+	if utils.DryRun() && ctx.Event != _config.PreAdd && ctx.Event != _config.PostRemove {
+		return ctx.Path
+	}
+	if info, err := os.Stat(ctx.Path); err == nil && info.IsDir() {
+		return ctx.Path
+	}
+	return ctx.RepoRoot
+}
+
+func resolvePath(p string, base string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(base, p)
+}
@@ -0,0 +1,115 @@
+package hooks
+
+import (
+	"bytes"
+	_config "github.com/jcelaya775/gwt/internal/config"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// record is a hook command that appends the name to the file named order in the worktree
+func record(name string) string {
+	return "echo " + name + " >> order"
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		hooks   _config.HookList
+		jobs    int
+		wantErr string   // Substring of the error, empty when the hooks succeed
+		want    []string // Hooks that ran, in order, or nil to check before instead
+		before  [][2]string
+	}{
+		{
+			name:  "sequential hooks run in order",
+			hooks: _config.HookList{{Run: record("a")}, {Run: record("b")}, {Run: record("c")}},
+			want:  []string{"a", "b", "c"},
+		},
+		{
+			name: "parallel hooks finish before the next sequential hook",
+			hooks: _config.HookList{
+				{Run: record("a")},
+				{Run: "sleep 0.2; " + record("slow"), Parallel: true},
+				{Run: record("fast"), Parallel: true},
+				{Run: record("d")},
+			},
+			jobs: 4,
+			want: []string{"a", "fast", "slow", "d"},
+		},
+		{
+			name: "needs wait for the named hooks only",
+			hooks: _config.HookList{
+				{Name: "install", Run: "sleep 0.2; " + record("install")},
+				{Name: "lint", Run: record("lint"), Needs: []string{}},
+				{Name: "build", Run: record("build"), Needs: []string{"install"}},
+				{Run: record("test"), Needs: []string{"build", "lint"}},
+			},
+			jobs:   4,
+			before: [][2]string{{"lint", "install"}, {"install", "build"}, {"build", "test"}, {"lint", "test"}},
+		},
+		{
+			name: "a failure keeps the hooks after it and its dependents from starting",
+			hooks: _config.HookList{
+				{Name: "install", Run: record("install") + "; exit 3"},
+				{Name: "build", Run: record("build"), Needs: []string{"install"}},
+				{Run: record("test")},
+			},
+			jobs:    4,
+			wantErr: "error running post_add hook 'echo install >> order; exit 3': exit status 3",
+			want:    []string{"install"},
+		},
+		{
+			name: "continue_on_error runs the hooks after a failure",
+			hooks: _config.HookList{
+				{Run: record("a") + "; exit 1", ContinueOnError: true},
+				{Run: record("b")},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "skipped hooks count as finished",
+			hooks: _config.HookList{
+				{Name: "install", Run: record("install"), If: _config.HookCondition{Exists: "package.json"}},
+				{Run: record("test"), Needs: []string{"install"}},
+			},
+			want: []string{"test"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			worktree := t.TempDir()
+			ctx := utils.CommandContext{Event: _config.PostAdd, Path: worktree, Name: "main", Branch: "main", RepoRoot: worktree}
+
+			var out bytes.Buffer
+			err := Run(tt.hooks, ctx, &out, tt.jobs)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Run() = %v, want no error", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Run() = %v, want an error containing %q", err, tt.wantErr)
+			}
+
+			content, err := os.ReadFile(filepath.Join(worktree, "order"))
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatal(err)
+			}
+			order := strings.Fields(string(content))
+			if tt.want != nil && !slices.Equal(order, tt.want) {
+				t.Errorf("hooks ran in order %q, want %q", order, tt.want)
+			}
+			for _, pair := range tt.before {
+				first, second := slices.Index(order, pair[0]), slices.Index(order, pair[1])
+				if first < 0 || second < 0 || first > second {
+					t.Errorf("hooks ran in order %q, want %s before %s", order, pair[0], pair[1])
+				}
+			}
+		})
+	}
+}
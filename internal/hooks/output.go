@@ -0,0 +1,140 @@
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prefixColors are the colours of hook name prefixes, cycled in the order of the hooks
+var prefixColors = []string{"#5FAFFF", "#AF87FF", "#FFA500", "#04B575", "#5FD7D7", "#FF87D7"}
+
+// prefixedOutput interleaves the output of parallel hooks line by line, prefixed with the hook's name
+type prefixedOutput struct {
+	mu       sync.Mutex
+	prefixes []string
+	writers  map[int][]*lineWriter
+}
+
+func newPrefixedOutput(names []string) *prefixedOutput {
+	width := 0
+	for _, name := range names {
+		width = max(width, lipgloss.Width(name))
+	}
+	prefixes := make([]string, len(names))
+	for i, name := range names {
+		style := lipgloss.NewStyle().Foreground(lipgloss.Color(prefixColors[i%len(prefixColors)]))
+		prefixes[i] = style.Render(name+strings.Repeat(" ", width-lipgloss.Width(name))) + " │ "
+	}
+	return &prefixedOutput{prefixes: prefixes, writers: make(map[int][]*lineWriter)}
+}
+
+// printLine writes a line of gwt's own output for the hook
+func (o *prefixedOutput) printLine(out io.Writer, i int, line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	fmt.Fprintln(out, o.prefixes[i]+line)
+}
+
+// writer returns a writer for the output of the hook that writes whole lines to out
+func (o *prefixedOutput) writer(out io.Writer, i int) io.Writer {
+	w := &lineWriter{output: o, out: out, hook: i}
+	o.mu.Lock()
+	o.writers[i] = append(o.writers[i], w)
+	o.mu.Unlock()
+	return w
+}
+
+// flush writes what is left of the hook's last line once it has finished
+func (o *prefixedOutput) flush(i int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, w := range o.writers[i] {
+		if w.partial.Len() > 0 {
+			fmt.Fprintln(w.out, o.prefixes[i]+w.partial.String())
+			w.partial.Reset()
+		}
+	}
+}
+
+type lineWriter struct {
+	output  *prefixedOutput
+	out     io.Writer
+	hook    int
+	partial bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.output.mu.Lock()
+	defer w.output.mu.Unlock()
+	w.partial.Write(p)
+	for {
+		line, err := w.partial.ReadString('\n')
+		if err != nil {
+			// Keep the incomplete line until the rest of it arrives
+			w.partial.Reset()
+			w.partial.WriteString(line)
+			return len(p), nil
+		}
+		if _, err := fmt.Fprint(w.out, w.output.prefixes[w.hook]+line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// printSummary prints a table of how each hook ended and how long it took
+func printSummary(out io.Writer, results []Result) {
+	greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
+	redStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F87"))
+	faintStyle := lipgloss.NewStyle().Faint(true)
+
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		status := string(result.Status)
+		switch result.Status {
+		case StatusOK:
+			status = greenStyle.Render(status)
+		case StatusFailed, StatusTimedOut:
+			status = redStyle.Render(status)
+		default:
+			status = faintStyle.Render(status)
+		}
+
+		exitCode := faintStyle.Render("-")
+		if result.ExitCode >= 0 {
+			exitCode = fmt.Sprint(result.ExitCode)
+		}
+		duration := faintStyle.Render("-")
+		if result.Status != StatusSkipped && result.Status != StatusNotRun {
+			duration = formatDuration(result.Duration)
+		}
+		rows = append(rows, []string{result.Name, status, exitCode, duration})
+	}
+
+	t := table.New().
+		Border(lipgloss.HiddenBorder()).
+		BorderTop(false).
+		BorderBottom(false).
+		BorderHeader(false).
+		Headers("HOOK", "STATUS", "EXIT", "DURATION").
+		Rows(rows...).
+		StyleFunc(func(row, col int) lipgloss.Style {
+			if row == table.HeaderRow {
+				return lipgloss.NewStyle().Bold(true)
+			}
+			return lipgloss.NewStyle()
+		})
+	fmt.Fprintln(out, t)
+}
+
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
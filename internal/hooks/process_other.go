@@ -0,0 +1,16 @@
+//go:build !unix
+
+package hooks
+
+import "os/exec"
+
+// killProcessGroup is a no-op where process groups are not available, so only the shell is stopped on cancellation
+func killProcessGroup(cmd *exec.Cmd) {}
+
+// detach is a no-op where sessions are not available, so the command only outlives gwt and not its terminal
+func detach(cmd *exec.Cmd) {}
+
+// processRunning assumes the process is running where its existence cannot be checked
+func processRunning(pid int) bool {
+	return true
+}
@@ -0,0 +1,28 @@
+//go:build unix
+
+package hooks
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup makes the command stop every process it starts when it is cancelled, not just the shell
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
+
+// detach starts the command in a session of its own, so it keeps running when the terminal gwt runs in is closed
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processRunning reports whether a process with the pid exists
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,282 @@
+package logs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/state"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// MaxLogsPerWorktree is how many logs are kept for each worktree before the oldest are removed
+	MaxLogsPerWorktree = 20
+	// MaxLogAge is how old a log can get before it is removed
+	MaxLogAge = 30 * 24 * time.Hour
+
+	fileTimeFormat = "20060102T150405.000Z"
+	lineTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Log records the output of the hooks or commands of a single event in a worktree, one timestamped line at a time
+type Log struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// Entry is a log file of a worktree
+type Entry struct {
+	Path  string
+	Event string
+	Time  time.Time
+}
+
+// WorktreeDir returns the directory holding the logs of a worktree of the repository
+func WorktreeDir(repoRoot string, worktree string) (string, error) {
+	stateDir, err := state.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "logs", state.RepoKey(repoRoot), url.PathEscape(worktree)), nil
+}
+
+// Create starts a new log for the event in the worktree and removes old logs of the worktree
+func Create(repoRoot string, worktree string, event string) (*Log, error) {
+	dir, err := WorktreeDir(repoRoot, worktree)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := rotate(dir); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, now.UTC().Format(fileTimeFormat)+"-"+event+".log")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	log := &Log{file: file, path: path}
+	log.Printf("gwt", "%s in worktree %s", event, worktree)
+	return log, nil
+}
+
+// Move moves the logs of a worktree to its new name, e.g. when it is renamed
+func Move(repoRoot string, worktree string, newWorktree string) error {
+	oldDir, err := WorktreeDir(repoRoot, worktree)
+	if err != nil {
+		return err
+	}
+	newDir, err := WorktreeDir(repoRoot, newWorktree)
+	if err != nil {
+		return err
+	}
+	files, err := os.ReadDir(oldDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(newDir, 0700); err != nil {
+		return err
+	}
+	// Logs kept under the new name, e.g. of a removed worktree, stay next to the moved ones
+	for _, file := range files {
+		if err := os.Rename(filepath.Join(oldDir, file.Name()), filepath.Join(newDir, file.Name())); err != nil {
+			return err
+		}
+	}
+	return os.Remove(oldDir)
+}
+
+// Path returns the path of the log file, or an empty string for a nil log
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Printf records a line from gwt itself about the named hook or command. Nil logs record nothing.
+func (l *Log) Printf(name string, format string, args ...any) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.writeLine(name, " ", fmt.Sprintf(format, args...))
+}
+
+// Writer returns a writer that records the output of the named hook or command line by line. Lines written to a
+// stderr writer are marked with an exclamation mark. Writers of nil logs discard everything.
+func (l *Log) Writer(name string, stderr bool) *LineWriter {
+	if l == nil {
+		return &LineWriter{}
+	}
+	marker := "|"
+	if stderr {
+		marker = "!"
+	}
+	return &LineWriter{log: l, name: name, marker: marker}
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+func (l *Log) writeLine(name string, marker string, line string) {
+	// Like a terminal, a carriage return starts the line over, e.g. for progress bars
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	_, _ = fmt.Fprintf(l.file, "%s [%s] %s %s\n", time.Now().Format(lineTimeFormat), name, marker, stripANSI(line))
+}
+
+// LineWriter records complete lines, keeping an incomplete line until the rest of it or Flush arrives
+type LineWriter struct {
+	log     *Log
+	name    string
+	marker  string
+	partial bytes.Buffer
+}
+
+func (w *LineWriter) Write(p []byte) (int, error) {
+	if w.log == nil {
+		return len(p), nil
+	}
+	w.log.mu.Lock()
+	defer w.log.mu.Unlock()
+	w.partial.Write(p)
+	for {
+		line, err := w.partial.ReadString('\n')
+		if err != nil {
+			w.partial.Reset()
+			w.partial.WriteString(line)
+			return len(p), nil
+		}
+		w.log.writeLine(w.name, w.marker, strings.TrimSuffix(line, "\n"))
+	}
+}
+
+// Flush records what is left of the last line
+func (w *LineWriter) Flush() {
+	if w.log == nil {
+		return
+	}
+	w.log.mu.Lock()
+	defer w.log.mu.Unlock()
+	if w.partial.Len() > 0 {
+		w.log.writeLine(w.name, w.marker, w.partial.String())
+		w.partial.Reset()
+	}
+}
+
+// ansiPattern matches terminal escape sequences for colours and cursor movement
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]|\x1b\][^\x07]*\x07`)
+
+func stripANSI(line string) string {
+	if !strings.Contains(line, "\x1b") {
+		return line
+	}
+	return ansiPattern.ReplaceAllString(line, "")
+}
+
+// Capture records every line read from r in the log file at path until r ends. It backs `gwt logs __capture`, which
+// tmux pipes the output of a session into while commands sent to it run.
+func Capture(path string, name string, r io.Reader) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	log := &Log{file: file, path: path}
+	defer log.Close()
+
+	w := log.Writer(name, false)
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	w.Flush()
+	return nil
+}
+
+// List returns the logs of the worktree from oldest to newest, only those of the event when it is not empty
+func List(repoRoot string, worktree string, event string) ([]Entry, error) {
+	dir, err := WorktreeDir(repoRoot, worktree)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := list(dir)
+	if err != nil {
+		return nil, err
+	}
+	if event != "" {
+		entries = slices.DeleteFunc(entries, func(e Entry) bool { return e.Event != event })
+	}
+	return entries, nil
+}
+
+func list(dir string) ([]Entry, error) {
+	files, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, file := range files {
+		name, ok := strings.CutSuffix(file.Name(), ".log")
+		if !ok || file.IsDir() {
+			continue
+		}
+		timestamp, event, ok := strings.Cut(name, "-")
+		if !ok {
+			continue
+		}
+		t, err := time.Parse(fileTimeFormat, timestamp)
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{Path: filepath.Join(dir, file.Name()), Event: event, Time: t})
+	}
+	slices.SortFunc(entries, func(a, b Entry) int { return a.Time.Compare(b.Time) })
+	return entries, nil
+}
+
+// rotate removes logs older than MaxLogAge, and the oldest logs beyond MaxLogsPerWorktree minus the one about to be
+// created
+func rotate(dir string) error {
+	entries, err := list(dir)
+	if err != nil {
+		return err
+	}
+	for i, entry := range entries {
+		if len(entries)-i < MaxLogsPerWorktree && time.Since(entry.Time) < MaxLogAge {
+			break
+		}
+		if err := os.Remove(entry.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLogs creates empty logs of the event in the worktree's log directory, one for each age
+func writeLogs(t *testing.T, dir string, event string, ages ...time.Duration) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	for _, age := range ages {
+		name := time.Now().Add(-age).UTC().Format(fileTimeFormat) + "-" + event + ".log"
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCreateRotates(t *testing.T) {
+	tests := []struct {
+		name      string
+		ages      []time.Duration
+		wantCount int
+		wantKept  time.Duration // Age of the oldest log that is kept
+	}{
+		{
+			name:      "fewer logs than the maximum are kept",
+			ages:      []time.Duration{time.Hour, 2 * time.Hour},
+			wantCount: 3,
+			wantKept:  2 * time.Hour,
+		},
+		{
+			name: "the oldest logs beyond the maximum are removed",
+			ages: func() []time.Duration {
+				var ages []time.Duration
+				for i := 1; i <= MaxLogsPerWorktree+5; i++ {
+					ages = append(ages, time.Duration(i)*time.Hour)
+				}
+				return ages
+			}(),
+			wantCount: MaxLogsPerWorktree,
+			wantKept:  (MaxLogsPerWorktree - 1) * time.Hour,
+		},
+		{
+			name:      "logs older than the maximum age are removed",
+			ages:      []time.Duration{time.Hour, 2 * time.Hour, MaxLogAge + time.Hour, MaxLogAge + 2*time.Hour},
+			wantCount: 3,
+			wantKept:  2 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_STATE_HOME", t.TempDir())
+			dir, err := WorktreeDir("/work/repo", "feature/login")
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeLogs(t, dir, "init", tt.ages...)
+
+			log, err := Create("/work/repo", "feature/login", "post_add")
+			if err != nil {
+				t.Fatal(err)
+			}
+			log.Close()
+
+			entries, err := List("/work/repo", "feature/login", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.wantCount {
+				t.Fatalf("%d logs are kept, want %d", len(entries), tt.wantCount)
+			}
+			if last := entries[len(entries)-1]; last.Path != log.Path() || last.Event != "post_add" {
+				t.Errorf("newest log = %+v, want the created one at %s", last, log.Path())
+			}
+			if age := time.Since(entries[0].Time).Round(time.Hour); age != tt.wantKept {
+				t.Errorf("oldest kept log is %s old, want %s", age, tt.wantKept)
+			}
+		})
+	}
+}
+
+func TestListEvent(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	dir, err := WorktreeDir("/work/repo", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, dir, "init", 3*time.Hour, time.Hour)
+	writeLogs(t, dir, "post_add", 2*time.Hour)
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := List("/work/repo", "main", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for _, entry := range entries {
+		events = append(events, entry.Event)
+	}
+	if !slices.Equal(events, []string{"init", "init"}) || !entries[0].Time.Before(entries[1].Time) {
+		t.Errorf("List() = %+v, want the two init logs from oldest to newest", entries)
+	}
+}
+
+func TestWriterLines(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	log, err := Create("/work/repo", "main", "init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := log.Writer("npm ci", true)
+	w.Write([]byte("\x1b[32mone\x1b[0m\ntw"))
+	w.Write([]byte("o\nprogress 10%\rprogress 100%"))
+	w.Flush()
+	log.Close()
+
+	content, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n")[1:] {
+		_, line, _ = strings.Cut(line, " ")
+		lines = append(lines, line)
+	}
+	want := []string{"[npm ci] ! one", "[npm ci] ! two", "[npm ci] ! progress 100%"}
+	if !slices.Equal(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestMove(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	oldDir, err := WorktreeDir("/work/repo", "feature/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDir, err := WorktreeDir("/work/repo", "feature/sign-in")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLogs(t, oldDir, "init", 2*time.Hour)
+	writeLogs(t, newDir, "post_add", 3*time.Hour)
+
+	if err := Move("/work/repo", "feature/login", "feature/sign-in"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Errorf("the old log directory is left behind")
+	}
+	entries, err := List("/work/repo", "feature/sign-in", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Event != "post_add" || entries[1].Event != "init" {
+		t.Errorf("List() = %+v, want the kept post_add log and the moved init log", entries)
+	}
+
+	if err := Move("/work/repo", "main", "trunk"); err != nil {
+		t.Errorf("Move() without logs = %v, want no error", err)
+	}
+}
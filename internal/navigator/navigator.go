@@ -0,0 +1,73 @@
+package navigator
+
+import (
+	"errors"
+	"github.com/jcelaya775/gwt/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CdFileEnv is set by the shell wrapper emitted by `gwt shell-init`. gwt writes the directory the parent
+// shell should change into to this file, and the wrapper cds into it once gwt exits.
+const CdFileEnv = "GWT_CD_FILE"
+
+var ErrShellIntegrationDisabled = errors.New("shell integration is not enabled. Add `eval \"$(gwt shell-init bash)\"` (or zsh/fish) to your shell config")
+
+type Navigator struct {
+	cdFile string
+}
+
+func New() *Navigator {
+	return &Navigator{cdFile: os.Getenv(CdFileEnv)}
+}
+
+// Enabled reports whether gwt is running inside the shell wrapper
+func (n *Navigator) Enabled() bool {
+	return n.cdFile != ""
+}
+
+// ChangeDir asks the parent shell to change into dir once gwt exits
+func (n *Navigator) ChangeDir(dir string) error {
+	if !n.Enabled() {
+		return ErrShellIntegrationDisabled
+	}
+	if utils.DryRun() {
+		utils.RecordDryRun("cd %s", utils.ShellQuote(dir))
+		return nil
+	}
+	return os.WriteFile(n.cdFile, []byte(dir), 0600)
+}
+
+// PreserveSubdir returns the directory in target that matches the current working directory's position
+// relative to source, falling back to target when that directory does not exist.
+func PreserveSubdir(source, target string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return target
+	}
+	if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+		cwd = resolved
+	}
+	if !IsWithin(cwd, source) {
+		return target
+	}
+	rel, err := filepath.Rel(source, cwd)
+	if err != nil || rel == "." {
+		return target
+	}
+	candidate := filepath.Join(target, rel)
+	if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+		return candidate
+	}
+	return target
+}
+
+// IsWithin reports whether path is dir or one of its descendants
+func IsWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, "../"))
+}
@@ -0,0 +1,48 @@
+package navigator
+
+import "fmt"
+
+const posixScript = `# gwt shell integration
+gwt() {
+  local gwt_cd_file gwt_status gwt_dir
+  gwt_cd_file="$(mktemp "${TMPDIR:-/tmp}/gwt-cd.XXXXXX")" || return
+  GWT_CD_FILE="$gwt_cd_file" command gwt "$@"
+  gwt_status=$?
+  if [ -s "$gwt_cd_file" ]; then
+    gwt_dir="$(cat "$gwt_cd_file")"
+    [ -d "$gwt_dir" ] && cd -- "$gwt_dir"
+  fi
+  rm -f -- "$gwt_cd_file"
+  return $gwt_status
+}
+`
+
+const fishScript = `# gwt shell integration
+function gwt
+    set -l gwt_tmpdir /tmp
+    set -q TMPDIR; and set gwt_tmpdir $TMPDIR
+    set -l gwt_cd_file (mktemp $gwt_tmpdir/gwt-cd.XXXXXX); or return
+    env GWT_CD_FILE=$gwt_cd_file gwt $argv
+    set -l gwt_status $status
+    if test -s $gwt_cd_file
+        set -l gwt_dir (cat $gwt_cd_file)
+        test -d "$gwt_dir"; and cd -- $gwt_dir
+    end
+    rm -f -- $gwt_cd_file
+    return $gwt_status
+end
+`
+
+var SupportedShells = []string{"bash", "zsh", "fish"}
+
+// Script returns the wrapper function for the given shell
+func Script(shell string) (string, error) {
+	switch shell {
+	case "bash", "zsh":
+		return posixScript, nil
+	case "fish":
+		return fishScript, nil
+	default:
+		return "", fmt.Errorf("unsupported shell '%s'. Supported shells: bash, zsh, fish", shell)
+	}
+}
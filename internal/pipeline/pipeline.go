@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Step is a stage of a command. Steps record what they create, so a failure in a later step can undo it.
+type Step struct {
+	Name string // What the step does, e.g. "create worktree"
+	Skip bool
+	Run  func(p *Pipeline) error
+}
+
+// Artefact is something a step created, such as a worktree or a branch, with how to remove it again
+type Artefact struct {
+	Description string
+	Undo        func() error
+}
+
+// Pipeline runs steps in order and keeps track of the artefacts they created
+type Pipeline struct {
+	artefacts []Artefact
+}
+
+func New() *Pipeline {
+	return &Pipeline{}
+}
+
+// StepError is the error of the step that stopped the pipeline. It reads like the step's own error.
+type StepError struct {
+	Step string
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Record adds an artefact the running step created
+func (p *Pipeline) Record(description string, undo func() error) {
+	p.artefacts = append(p.artefacts, Artefact{Description: description, Undo: undo})
+}
+
+// Run runs the steps that are not skipped in order, stopping at the first that fails with a StepError
+func (p *Pipeline) Run(steps []Step) error {
+	for _, step := range steps {
+		if step.Skip {
+			continue
+		}
+		if err := step.Run(p); err != nil {
+			return &StepError{Step: step.Name, Err: err}
+		}
+	}
+	return nil
+}
+
+// Artefacts returns the artefacts recorded so far, in the order they were created
+func (p *Pipeline) Artefacts() []Artefact {
+	return p.artefacts
+}
+
+// Rollback undoes the artefacts in reverse order, calling done after each one. It carries on past artefacts that
+// cannot be undone and returns their errors together.
+func (p *Pipeline) Rollback(done func(artefact Artefact, err error)) error {
+	var errs []error
+	for i := len(p.artefacts) - 1; i >= 0; i-- {
+		artefact := p.artefacts[i]
+		err := artefact.Undo()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error removing %s: %w", artefact.Description, err))
+		}
+		if done != nil {
+			done(artefact, err)
+		}
+	}
+	p.artefacts = nil
+	return errors.Join(errs...)
+}
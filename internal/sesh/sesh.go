@@ -17,11 +17,20 @@ func New(shell shell.Shell) *Sesh {
 }
 
 func (s *Sesh) SessionExists(worktree string) (bool, error) {
+	session, err := s.FindSession(worktree)
+	if err != nil {
+		return false, err
+	}
+	return session != "", nil
+}
+
+// FindSession returns the name of the session for the worktree as listed by sesh, or an empty string if there is none
+func (s *Sesh) FindSession(worktree string) (string, error) {
 	sessionName := normalize(path.Base(worktree))
 
 	sessions, err := s.shell.Cmd("sesh", "list")
 	if err != nil {
-		return false, err
+		return "", err
 	}
 
 	for _, session := range strings.Split(sessions, "\n") {
@@ -31,11 +40,11 @@ func (s *Sesh) SessionExists(worktree string) (bool, error) {
 		}
 
 		if normalize(session) == sessionName {
-			return true, nil
+			return session, nil
 		}
 	}
 
-	return false, nil
+	return "", nil
 }
 
 // normalize makes a name comparable by:
@@ -0,0 +1,28 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+)
+
+// Dir returns $XDG_STATE_HOME/gwt, falling back to ~/.local/state/gwt
+func Dir() (string, error) {
+	stateHome := os.Getenv("XDG_STATE_HOME")
+	if stateHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		stateHome = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(stateHome, "gwt"), nil
+}
+
+// RepoKey names the state of a repository after its directory and a hash of its path, e.g. gwt-3f2a1b9c, so
+// repositories with the same name do not share state
+func RepoKey(repoRoot string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(repoRoot)))
+	return filepath.Base(repoRoot) + "-" + hex.EncodeToString(sum[:4])
+}
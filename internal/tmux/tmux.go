@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/jcelaya775/gwt/internal/shell"
+	"github.com/jcelaya775/gwt/internal/utils"
 	"os/exec"
 	"strings"
 	"time"
@@ -27,6 +28,48 @@ func (t *Tmux) SendKeys(session string, cmdStr string) error {
 	return nil
 }
 
+// RenameSession renames a running tmux session. It does nothing if the session does not exist.
+func (t *Tmux) RenameSession(session string, newName string) error {
+	if err := exec.Command("tmux", "has-session", "-t", "="+session).Run(); err != nil {
+		return nil
+	}
+	output, err := utils.Execute(exec.Command("tmux", "rename-session", "-t", "="+session, newName))
+	if err != nil {
+		return fmt.Errorf("error renaming tmux session '%s': %s", session, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// ListSessions returns the names of the running tmux sessions, or none if the tmux server is not running
+func (t *Tmux) ListSessions() ([]string, error) {
+	output, err := exec.Command("tmux", "list-sessions", "-F", "#{session_name}").CombinedOutput()
+	if err != nil {
+		if _, lookErr := exec.LookPath("tmux"); lookErr != nil || strings.Contains(string(output), "no server running") {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error listing tmux sessions: %s", strings.TrimSpace(string(output)))
+	}
+	var sessions []string
+	for _, session := range strings.Split(string(output), "\n") {
+		if session != "" {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// KillSession stops a tmux session and every process running in it. It does nothing if the session does not exist.
+func (t *Tmux) KillSession(session string) error {
+	if err := exec.Command("tmux", "has-session", "-t", "="+session).Run(); err != nil {
+		return nil
+	}
+	output, err := utils.Execute(exec.Command("tmux", "kill-session", "-t", "="+session))
+	if err != nil {
+		return fmt.Errorf("error stopping tmux session '%s': %s", session, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 // waitForTmuxWindowActive waits until the tmux target session:window reports window_active == 1.
 // `window` may be a window index ("0") or name ("editor"). Timeout controls how long to wait.
 func waitForTmuxWindowActive(session, window string, timeout time.Duration) error {
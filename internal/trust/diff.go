@@ -0,0 +1,59 @@
+package trust
+
+// Change marks a line of a diff
+type Change byte
+
+const (
+	Unchanged Change = ' '
+	Added     Change = '+'
+	Removed   Change = '-'
+)
+
+// DiffLine is a line of a diff
+type DiffLine struct {
+	Change Change
+	Text   string
+}
+
+// Diff returns the lines of old and new in order, marking the ones only in old as removed and the ones only in new as
+// added. Lines in both are found through their longest common subsequence.
+func Diff(old, new []string) []DiffLine {
+	// common[i][j] is the length of the longest common subsequence of old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, max(len(old), len(new)))
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			lines = append(lines, DiffLine{Change: Unchanged, Text: old[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, DiffLine{Change: Removed, Text: old[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Change: Added, Text: new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		lines = append(lines, DiffLine{Change: Removed, Text: old[i]})
+	}
+	for ; j < len(new); j++ {
+		lines = append(lines, DiffLine{Change: Added, Text: new[j]})
+	}
+	return lines
+}
@@ -0,0 +1,106 @@
+package trust
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jcelaya775/gwt/internal/state"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Approval is the set of commands of a repository's .gwt.yml the user trusted last. Only the hash decides whether the
+// commands are still trusted, the commands themselves are kept to show what changed since.
+type Approval struct {
+	Hash     string    `json:"hash"`
+	Commands []string  `json:"commands"`
+	Trusted  time.Time `json:"trusted"`
+}
+
+// Status compares the commands of a repository's .gwt.yml with the ones the user trusted
+type Status struct {
+	Commands []string  // Commands in .gwt.yml, as returned by config.RepoCommands
+	Approval *Approval // Last approval, nil if the user never trusted the repository
+}
+
+// Trusted reports whether the commands can run. A .gwt.yml without commands needs no approval.
+func (s *Status) Trusted() bool {
+	if len(s.Commands) == 0 {
+		return true
+	}
+	return s.Approval != nil && s.Approval.Hash == Hash(s.Commands)
+}
+
+// Hash identifies a set of commands. The commands are the "key: value" lines of config.RepoCommands, so the key of every
+// command is hashed with it. Each line is hashed with its length first, so lines containing line breaks or empty lines
+// cannot make two different sets hash the same.
+func Hash(commands []string) string {
+	hash := sha256.New()
+	for _, command := range commands {
+		_, _ = fmt.Fprintf(hash, "%d:%s", len(command), command)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// approvalPath returns the path of the file the repository's approval is kept in
+func approvalPath(repoRoot string) (string, error) {
+	stateDir, err := state.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(stateDir, "trust", state.RepoKey(repoRoot)+".json"), nil
+}
+
+// Check compares the commands with the last approval of the repository
+func Check(repoRoot string, commands []string) (*Status, error) {
+	path, err := approvalPath(repoRoot)
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Commands: commands}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var approval Approval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("invalid trust file %s: %w", path, err)
+	}
+	status.Approval = &approval
+	return status, nil
+}
+
+// Trust records the commands as the repository's approval
+func Trust(repoRoot string, commands []string) error {
+	path, err := approvalPath(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(Approval{Hash: Hash(commands), Commands: commands, Trusted: time.Now()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Revoke forgets the repository's approval, so its commands need to be trusted again
+func Revoke(repoRoot string) error {
+	path, err := approvalPath(repoRoot)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package trust
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  []string
+		new  []string
+		want string // Change markers of the diff, one per line
+	}{
+		{name: "unchanged", old: []string{"a", "b"}, new: []string{"a", "b"}, want: "  "},
+		{name: "everything added", new: []string{"a", "b"}, want: "++"},
+		{name: "everything removed", old: []string{"a", "b"}, want: "--"},
+		{name: "line changed", old: []string{"a", "b", "c"}, new: []string{"a", "x", "c"}, want: " -+ "},
+		{name: "line inserted", old: []string{"a", "c"}, new: []string{"a", "b", "c"}, want: " + "},
+		{name: "lines moved", old: []string{"a", "b", "c"}, new: []string{"c", "a", "b"}, want: "+  -"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := Diff(tt.old, tt.new)
+			changes := make([]byte, len(lines))
+			var old, new []string
+			for i, line := range lines {
+				changes[i] = byte(line.Change)
+				if line.Change != Added {
+					old = append(old, line.Text)
+				}
+				if line.Change != Removed {
+					new = append(new, line.Text)
+				}
+			}
+			if string(changes) != tt.want {
+				t.Errorf("Diff() = %+v, want changes %q", lines, tt.want)
+			}
+			if !reflect.DeepEqual(old, tt.old) || !reflect.DeepEqual(new, tt.new) {
+				t.Errorf("Diff() = %+v does not give back %q and %q", lines, tt.old, tt.new)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	commands := []string{"hooks.post_add[0]: npm ci", "init_commands[0]: make"}
+	if Hash(commands) != Hash([]string{"hooks.post_add[0]: npm ci", "init_commands[0]: make"}) {
+		t.Error("Hash() differs for equal commands")
+	}
+
+	changed := [][]string{
+		nil,
+		{"hooks.post_add[0]: npm ci"},
+		{"init_commands[0]: make", "hooks.post_add[0]: npm ci"},
+		{"hooks.post_add[0]: npm ci", "init_commands[0]: make test"},
+		{"hooks.post_add[0]: npm ci", "destroy_commands[0]: make"},
+	}
+	for _, other := range changed {
+		if Hash(other) == Hash(commands) {
+			t.Errorf("Hash(%q) = Hash(%q)", other, commands)
+		}
+	}
+}
+
+func TestTrust(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	commands := []string{"init_commands[0]: make"}
+
+	status, err := Check("/work/repo", commands)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Trusted() || status.Approval != nil {
+		t.Fatalf("Check() before trusting = %+v, want no approval", status)
+	}
+	if status, _ := Check("/work/repo", nil); !status.Trusted() {
+		t.Error("a config without commands needs approval")
+	}
+
+	if err := Trust("/work/repo", commands); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := Check("/work/repo", commands); !status.Trusted() {
+		t.Error("trusted commands are not trusted")
+	}
+	status, _ = Check("/work/repo", []string{"init_commands[0]: make install"})
+	if status.Trusted() || !reflect.DeepEqual(status.Approval.Commands, commands) {
+		t.Errorf("changed commands = %+v, want untrusted with the last approval", status)
+	}
+	if status, _ := Check("/work/other", commands); status.Trusted() {
+		t.Error("commands trusted in one repository are trusted in another")
+	}
+
+	if err := Revoke("/work/repo"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := Check("/work/repo", commands); status.Trusted() {
+		t.Error("revoked commands are still trusted")
+	}
+	if err := Revoke("/work/repo"); err != nil {
+		t.Errorf("revoking twice = %v, want no error", err)
+	}
+}
+
+func TestHashLineBreaks(t *testing.T) {
+	pairs := [][2][]string{
+		{{"init_commands[0]: make\ninit_commands[1]: make test"}, {"init_commands[0]: make", "init_commands[1]: make test"}},
+		{{"a", ""}, {"a\n"}},
+		{{""}, nil},
+	}
+	for _, pair := range pairs {
+		if Hash(pair[0]) == Hash(pair[1]) {
+			t.Errorf("Hash(%q) = Hash(%q)", pair[0], pair[1])
+		}
+	}
+}
@@ -1,52 +1,198 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/jcelaya775/gwt/internal/logs"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"text/template"
+	"time"
 )
 
-func RunCommands(commands []string, worktreePath string, sesh bool, worktree string) error {
+// CommandContext describes the worktree commands run for. It is exported to the commands as GWT_* environment
+// variables and is the data of command templates, e.g. {{.Branch}} or {{shellquote .Path}}.
+type CommandContext struct {
+	Event      string // Event the commands run for, e.g. init or destroy
+	Path       string // Absolute path of the worktree
+	Name       string // Name of the worktree
+	Branch     string // Branch checked out in the worktree
+	BaseBranch string // Branch or commit the worktree's branch is based on
+	RepoRoot   string // Absolute path of the repository root
+	RepoName   string // Name of the repository root directory
+}
+
+// Env returns the GWT_* environment variables of the context
+func (c CommandContext) Env() []string {
+	return []string{
+		"GWT_WORKTREE_PATH=" + c.Path,
+		"GWT_WORKTREE_NAME=" + c.Name,
+		"GWT_BRANCH=" + c.Branch,
+		"GWT_BASE_BRANCH=" + c.BaseBranch,
+		"GWT_REPO_ROOT=" + c.RepoRoot,
+		"GWT_REPO_NAME=" + c.RepoName,
+		"GWT_EVENT=" + c.Event,
+	}
+}
+
+var commandFuncs = template.FuncMap{
+	"shellquote": ShellQuote,
+}
+
+// ExpandCommand expands the Go template in a command with the context
+func ExpandCommand(command string, ctx CommandContext) (string, error) {
+	if !strings.Contains(command, "{{") {
+		return command, nil
+	}
+	tmpl, err := template.New("command").Funcs(commandFuncs).Option("missingkey=error").Parse(command)
+	if err != nil {
+		return "", fmt.Errorf("invalid template in command '%s': %w", command, err)
+	}
+	var expanded strings.Builder
+	if err := tmpl.Execute(&expanded, ctx); err != nil {
+		return "", fmt.Errorf("invalid template in command '%s': %w", command, err)
+	}
+	return expanded.String(), nil
+}
+
+// ShellQuote quotes a string for POSIX shells
+func ShellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./=:,+@%", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// RunCommands runs the commands in the worktree, or sends them to the worktree's tmux session when sesh is set.
+// Templates in the commands are expanded before any command runs, and the GWT_* variables of the context are exported.
+// The output, exit code and duration of each command are kept in the worktree's log of the event. In the tmux session,
+// the log captures the output of the session's pane until the last command sent to it has finished.
+func RunCommands(commands []string, ctx CommandContext, sesh bool) error {
+	expandedCommands := make([]string, 0, len(commands))
+	for _, command := range commands {
+		expanded, err := ExpandCommand(command, ctx)
+		if err != nil {
+			return err
+		}
+		expandedCommands = append(expandedCommands, expanded)
+	}
+	if len(expandedCommands) == 0 {
+		return nil
+	}
+	if dryRun {
+		for _, command := range expandedCommands {
+			if sesh {
+				Execute(exec.Command("tmux", "send-keys", "-t", filepath.Base(ctx.Path), command, "C-m"))
+				continue
+			}
+			execCmd := exec.Command("sh", "-c", command)
+			execCmd.Dir = ctx.Path
+			RecordDryRun("%s command: %s", ctx.Event, FormatCommand(execCmd))
+		}
+		return nil
+	}
+
+	// Commands still run when the log cannot be written, e.g. on a read-only home directory
+	log, err := logs.Create(ctx.RepoRoot, ctx.Name, ctx.Event)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: cannot write the log of the %s commands: %s\n", ctx.Event, err)
+	}
+	defer log.Close()
+
 	var styledCommandText string
 	greenStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#04B575"))
 	orangeStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#FFA500"))
-	for i, command := range commands {
+	session := filepath.Base(ctx.Path)
+	if sesh {
+		// The session's shell keeps the variables for every command sent after them. The leading space keeps the
+		// export out of the shell history when HISTCONTROL ignores commands starting with a space.
+		exports := make([]string, 0, len(ctx.Env()))
+		for _, variable := range ctx.Env() {
+			name, value, _ := strings.Cut(variable, "=")
+			exports = append(exports, name+"="+ShellQuote(value))
+		}
+		if output, err := Execute(exec.Command("tmux", "send-keys", "-t", session, " export "+strings.Join(exports, " "), "C-m")); err != nil {
+			return fmt.Errorf("error exporting GWT variables to tmux session '%s': %s", session, strings.TrimSpace(string(output)))
+		}
+		if log != nil {
+			if err := capturePane(session, log.Path()); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: cannot capture the output of tmux session '%s': %s\n", session, err)
+			} else {
+				// The shell runs the commands in order, so it stops capturing once the last of them has finished
+				defer Execute(exec.Command("tmux", "send-keys", "-t", session, " tmux pipe-pane -t "+ShellQuote(session), "C-m"))
+			}
+		}
+	}
+
+	for i, command := range expandedCommands {
+		name := fmt.Sprintf("command %d", i+1)
+		logStdout, logStderr := log.Writer(name, false), log.Writer(name, true)
 		var execCmd *exec.Cmd
 		if sesh {
-			session := filepath.Base(worktreePath)
 			execCmd = exec.Command("tmux", "send-keys", "-t", session, command, "C-m")
-			execCmd.Stdout = os.Stdout
-			execCmd.Stderr = os.Stderr
-			execCmd.Stdin = os.Stdin
+			log.Printf(name, "sending %s to tmux session %s", command, session)
 
 			styledCommandText = greenStyle.Render(fmt.Sprintf("tmux send-keys -t %s ", session)) +
 				orangeStyle.Render(command) + greenStyle.Render(" C-m")
 		} else {
 			execCmd = exec.Command("sh", "-c", command)
-			execCmd.Dir = worktreePath
-			execCmd.Stdout = os.Stdout
-			execCmd.Stderr = os.Stderr
+			execCmd.Dir = ctx.Path
+			execCmd.Env = append(os.Environ(), ctx.Env()...)
+			execCmd.Stdout = io.MultiWriter(os.Stdout, logStdout)
+			execCmd.Stderr = io.MultiWriter(os.Stderr, logStderr)
 			execCmd.Stdin = os.Stdin
+			log.Printf(name, "running %s in %s", command, ctx.Path)
 
 			styledCommandText = greenStyle.Render(command)
 		}
 
 		boldStyle := lipgloss.NewStyle().Bold(true)
-		var text string
-		if worktree != "" {
-			text = boldStyle.Render(fmt.Sprintf("️➡️ Running init command %d of %s in worktree %s: %s...",
-				i+1, strconv.Itoa(len(commands)), orangeStyle.Render(worktree), styledCommandText))
+		text := boldStyle.Render(fmt.Sprintf("️➡️ Running %s command %d of %s in worktree %s: %s...",
+			ctx.Event, i+1, strconv.Itoa(len(expandedCommands)), orangeStyle.Render(ctx.Name), styledCommandText))
+		fmt.Println(text)
+		start := time.Now()
+		var err error
+		if sesh {
+			var output []byte
+			output, err = Execute(execCmd)
+			os.Stderr.Write(output)
 		} else {
-			text = boldStyle.Render(fmt.Sprintf("️➡️ Running init command %d of %s: %s...",
-				i+1, strconv.Itoa(len(commands)), styledCommandText))
+			err = execCmd.Run()
+			logStdout.Flush()
+			logStderr.Flush()
+			exitCode := -1
+			if execCmd.ProcessState != nil {
+				exitCode = execCmd.ProcessState.ExitCode()
+			}
+			log.Printf(name, "exited with code %d after %s", exitCode, time.Since(start).Round(time.Millisecond))
 		}
-		fmt.Println(text)
-		if err := execCmd.Run(); err != nil {
-			return fmt.Errorf("error running init command '%s': %w", command, err)
+		if err != nil {
+			if log != nil {
+				fmt.Fprintln(os.Stderr, lipgloss.NewStyle().Faint(true).Render(fmt.Sprintf("The output of the commands is kept in %s", log.Path())))
+			}
+			return fmt.Errorf("error running %s command '%s': %w", ctx.Event, command, err)
 		}
 	}
 	return nil
 }
+
+// capturePane pipes the output of the tmux session's pane into the log file through the hidden gwt logs __capture,
+// replacing any pipe opened before
+func capturePane(session string, logPath string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	capture := ShellQuote(executable) + " logs __capture " + ShellQuote(logPath)
+	if output, err := Execute(exec.Command("tmux", "pipe-pane", "-t", session, capture)); err != nil {
+		return errors.New(strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "main", want: "main"},
+		{in: "feature/login-page_2", want: "feature/login-page_2"},
+		{in: "/work/repo/a=b:c,d+e@f%g", want: "/work/repo/a=b:c,d+e@f%g"},
+		{in: "", want: "''"},
+		{in: "my worktree", want: "'my worktree'"},
+		{in: "it's", want: `'it'\''s'`},
+		{in: "$(rm -rf ~)", want: "'$(rm -rf ~)'"},
+		{in: "a;b|c&d", want: "'a;b|c&d'"},
+		{in: "naïve", want: "'naïve'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := ShellQuote(tt.in)
+			if got != tt.want {
+				t.Fatalf("ShellQuote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+			output, err := exec.Command("sh", "-c", "printf %s "+got).Output()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(output) != tt.in {
+				t.Errorf("sh read %s as %q, want %q", got, output, tt.in)
+			}
+		})
+	}
+}
+
+func TestExpandCommand(t *testing.T) {
+	ctx := CommandContext{
+		Event:      "init",
+		Path:       "/work/my repo/feature/login",
+		Name:       "feature/login",
+		Branch:     "feature/login",
+		BaseBranch: "main",
+		RepoRoot:   "/work/my repo",
+		RepoName:   "my repo",
+	}
+
+	tests := []struct {
+		command string
+		want    string
+		wantErr string
+	}{
+		{command: "npm ci", want: "npm ci"},
+		{command: "echo {not a template}", want: "echo {not a template}"},
+		{command: "echo {{.Branch}} {{.BaseBranch}} {{.Event}}", want: "echo feature/login main init"},
+		{command: "cd {{shellquote .RepoRoot}}", want: "cd '/work/my repo'"},
+		{command: "code {{.Path | shellquote}}", want: "code '/work/my repo/feature/login'"},
+		{command: "echo {{.Nope}}", wantErr: "invalid template in command 'echo {{.Nope}}'"},
+		{command: "echo {{.Branch", wantErr: "invalid template in command 'echo {{.Branch'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			got, err := ExpandCommand(tt.command, ctx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExpandCommand() = %q, %v, want an error containing %q", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ExpandCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
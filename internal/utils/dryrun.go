@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/charmbracelet/lipgloss"
+	"os/exec"
+	"strings"
+)
+
+var dryRun bool
+
+// SetDryRun makes commands that change the repository or the file system print what they would do instead
+func SetDryRun(enabled bool) {
+	dryRun = enabled
+}
+
+// DryRun reports whether gwt only prints what it would do
+func DryRun() bool {
+	return dryRun
+}
+
+// RecordDryRun prints something gwt would do in a dry run
+func RecordDryRun(format string, args ...any) {
+	faintStyle := lipgloss.NewStyle().Faint(true)
+	fmt.Println(faintStyle.Render("[dry-run]") + " " + fmt.Sprintf(format, args...))
+}
+
+// Execute runs a command that changes something and returns its combined output. In a dry run it prints the command
+// instead and returns no output. Every command that changes a repository or tmux runs through Execute, so a dry run
+// cannot run one by accident.
+func Execute(cmd *exec.Cmd) ([]byte, error) {
+	if dryRun {
+		RecordDryRun("%s", FormatCommand(cmd))
+		return nil, nil
+	}
+	return cmd.CombinedOutput()
+}
+
+// FormatCommand renders a command as a shell command line, changing into its directory first when it has one
+func FormatCommand(cmd *exec.Cmd) string {
+	args := make([]string, 0, len(cmd.Args))
+	for _, arg := range cmd.Args {
+		args = append(args, ShellQuote(arg))
+	}
+	line := strings.Join(args, " ")
+	if cmd.Dir != "" {
+		line = "cd " + ShellQuote(cmd.Dir) + " && " + line
+	}
+	return line
+}
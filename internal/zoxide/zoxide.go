@@ -2,6 +2,7 @@ package zoxide
 
 import (
 	"github.com/jcelaya775/gwt/internal/shell"
+	"github.com/jcelaya775/gwt/internal/utils"
 )
 
 type Zoxide struct {
@@ -13,11 +14,19 @@ func New(shell shell.Shell) *Zoxide {
 }
 
 func (z *Zoxide) AddPath(path string) error {
+	if utils.DryRun() {
+		utils.RecordDryRun("zoxide add %s", utils.ShellQuote(path))
+		return nil
+	}
 	_, err := z.shell.Cmd("zoxide", "add", path)
 	return err
 }
 
 func (z *Zoxide) RemovePath(path string) error {
+	if utils.DryRun() {
+		utils.RecordDryRun("zoxide remove %s", utils.ShellQuote(path))
+		return nil
+	}
 	_, err := z.shell.Cmd("zoxide", "remove", path)
 	return err
 }